@@ -0,0 +1,165 @@
+package tpke
+
+import (
+	bls "github.com/kilic/bls12-381"
+)
+
+// AggregateSignatures compresses signatures of the same mode into one, e.g. from different committees or epochs
+func AggregateSignatures(sigs []*Signature) (*Signature, error) {
+	if len(sigs) == 0 {
+		return nil, NewSigAggregationError()
+	}
+	mode := sigs[0].Mode()
+	for i := 1; i < len(sigs); i++ {
+		if sigs[i].Mode() != mode {
+			return nil, NewSigAggregationError()
+		}
+	}
+	if mode == MinSigSize {
+		g1 := bls.NewG1()
+		pg1 := g1.Zero()
+		for i := 0; i < len(sigs); i++ {
+			g1.Add(pg1, pg1, sigs[i].pg1)
+		}
+		return &Signature{
+			pg1: pg1,
+		}, nil
+	}
+	g2 := bls.NewG2()
+	pg2 := g2.Zero()
+	for i := 0; i < len(sigs); i++ {
+		g2.Add(pg2, pg2, sigs[i].pg2)
+	}
+	return NewSignature(pg2), nil
+}
+
+// AggregatePublicKeys adds up keys, the G2 form is kept only if every key has one.
+// Keys must come with valid proofs of possession, or a rogue key can forge the aggregate.
+func AggregatePublicKeys(pks []*PublicKey) (*PublicKey, error) {
+	if len(pks) == 0 {
+		return nil, NewSigAggregationError()
+	}
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	pg1 := g1.Zero()
+	pg2 := g2.Zero()
+	for i := 0; i < len(pks); i++ {
+		g1.Add(pg1, pg1, pks[i].pg1)
+		if pg2 != nil && pks[i].pg2 != nil {
+			g2.Add(pg2, pg2, pks[i].pg2)
+		} else {
+			pg2 = nil
+		}
+	}
+	return &PublicKey{
+		pg1: pg1,
+		pg2: pg2,
+	}, nil
+}
+
+// NewPossessionContext takes the standard tag of proofs of possession, e.g. BLS_POP_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_
+func NewPossessionContext(mode SignatureMode) *SigningContext {
+	group := "G2"
+	if mode == MinSigSize {
+		group = "G1"
+	}
+	return &SigningContext{
+		mode:   mode,
+		scheme: PopScheme,
+		dst:    []byte("BLS_POP_BLS12381" + group + "_XMD:SHA-256_SSWU_RO_POP_"),
+	}
+}
+
+// PossessionMessage is the key in the key group, committees sign it with NewPossessionContext to prove their global public key
+func PossessionMessage(pk *PublicKey, mode SignatureMode) []byte {
+	if mode == MinSigSize {
+		if pk.pg2 == nil {
+			return nil
+		}
+		return bls.NewG2().ToCompressed(pk.pg2)
+	}
+	return bls.NewG1().ToCompressed(pk.pg1)
+}
+
+func (sk *PrivateKey) ProvePossession(mode SignatureMode) *Signature {
+	msg := PossessionMessage(sk.GetPublicKey(), mode)
+	// Possession context has no augmentation, which never fails
+	share, _ := sk.SignShareWithContext(NewPossessionContext(mode), msg)
+	return (*Signature)(share)
+}
+
+func (pk *PublicKey) VerifyPossession(proof *Signature) bool {
+	if proof.Mode() == MinSigSize && pk.pg2 == nil {
+		return false
+	}
+	msg := PossessionMessage(pk, proof.Mode())
+	return pk.VerifySigWithContext(NewPossessionContext(proof.Mode()), msg, proof)
+}
+
+// AggregateVerify takes no proofs of possession, so that messages must be distinct as in basic scheme
+func AggregateVerify(pks []*PublicKey, msgs [][]byte, sig *Signature) bool {
+	return AggregateVerifyWithContext(defaultSigningContext(sig.Mode()), pks, msgs, sig)
+}
+
+// AggregateVerifyWithContext checks an aggregate of signatures on different messages by different keys.
+// In augmentation scheme, every message is prefixed with its own key. Otherwise messages must be distinct,
+// since keys come without proofs of possession and a rogue key can cancel another on a repeated message.
+func AggregateVerifyWithContext(ctx *SigningContext, pks []*PublicKey, msgs [][]byte, sig *Signature) bool {
+	if len(pks) == 0 || len(pks) != len(msgs) || sig.Mode() != ctx.mode {
+		return false
+	}
+	if ctx.scheme != AugScheme {
+		seen := make(map[string]bool)
+		for _, msg := range msgs {
+			if seen[string(msg)] {
+				return false
+			}
+			seen[string(msg)] = true
+		}
+	}
+	// Keys of an aggregate are never bound to a single one
+	ctx = ctx.WithKey(nil)
+
+	pairing := bls.NewEngine()
+	if ctx.mode == MinSigSize {
+		// prod(e(Hi,pki'))==e(S,G2)
+		for i := 0; i < len(pks); i++ {
+			if pks[i].pg2 == nil {
+				return false
+			}
+			g1Hash, err := ctx.hashToG1(pks[i], msgs[i])
+			if err != nil {
+				return false
+			}
+			pairing.AddPair(g1Hash, pks[i].pg2)
+		}
+		return pairing.AddPairInv(sig.pg1, &bls.G2One).Check()
+	}
+	// prod(e(pki,Hi))==e(G1,S)
+	for i := 0; i < len(pks); i++ {
+		pairing.AddPair(pks[i].pg1, ctx.hashToG2(pks[i], msgs[i]))
+	}
+	return pairing.AddPairInv(&bls.G1One, sig.pg2).Check()
+}
+
+func FastAggregateVerify(pks []*PublicKey, pops []*Signature, msg []byte, sig *Signature) bool {
+	return FastAggregateVerifyWithContext(defaultSigningContext(sig.Mode()), pks, pops, msg, sig)
+}
+
+// FastAggregateVerifyWithContext checks an aggregate of signatures on the same message with two pairings.
+// Every key is checked against its proof of possession first, which only the proof of possession scheme allows.
+func FastAggregateVerifyWithContext(ctx *SigningContext, pks []*PublicKey, pops []*Signature, msg []byte, sig *Signature) bool {
+	if ctx.scheme != PopScheme || len(pks) != len(pops) {
+		return false
+	}
+	for i := 0; i < len(pks); i++ {
+		if pops[i].Mode() != ctx.mode || !pks[i].VerifyPossession(pops[i]) {
+			return false
+		}
+	}
+	pk, err := AggregatePublicKeys(pks)
+	if err != nil {
+		return false
+	}
+	return pk.VerifySigWithContext(ctx, msg, sig)
+}
@@ -0,0 +1,120 @@
+package tpke
+
+import (
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestAggregateVerify(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+	vks := dkg.PublishVerificationKeys()
+
+	// Committee signs its own key to prove possession
+	popShares := make(map[int]*SignatureShare)
+	msgShares := make(map[int]*SignatureShare)
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+	for i := 1; i <= size; i++ {
+		popShares[i], _ = prvkeys[i].SignShareWithContext(NewPossessionContext(MinPubKeySize), PossessionMessage(pubkey, MinPubKeySize))
+		msgShares[i] = prvkeys[i].SignShare(msg)
+	}
+	pop, _, err := AggregateAndVerifySigWithContext(NewPossessionContext(MinPubKeySize), pubkey, vks, PossessionMessage(pubkey, MinPubKeySize), threshold, popShares, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	sig, _, err := AggregateAndVerifySigWithKeys(pubkey, vks, msg, threshold, msgShares, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Another signer
+	sk := &PrivateKey{
+		fr: RandScalar(),
+	}
+	pk := sk.GetPublicKey()
+	pops := []*Signature{pop, sk.ProvePossession(MinPubKeySize)}
+	pks := []*PublicKey{pubkey, pk}
+
+	// Same message
+	aggregated, err := AggregateSignatures([]*Signature{sig, (*Signature)(sk.SignShare(msg))})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !FastAggregateVerify(pks, pops, msg, aggregated) {
+		t.Fatalf("invalid aggregated signature")
+	}
+	if FastAggregateVerify(pks, []*Signature{pop, pop}, msg, aggregated) {
+		t.Fatalf("verified with an invalid proof of possession")
+	}
+
+	// Distinct messages
+	other := []byte("pizza")
+	aggregated, err = AggregateSignatures([]*Signature{sig, (*Signature)(sk.SignShare(other))})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !AggregateVerify(pks, [][]byte{msg, other}, aggregated) {
+		t.Fatalf("invalid aggregated signature")
+	}
+	if AggregateVerify(pks, [][]byte{other, msg}, aggregated) {
+		t.Fatalf("verified with swapped messages")
+	}
+	if _, err := AggregateSignatures([]*Signature{sig, (*Signature)(sk.SignShareWithMode(other, MinSigSize))}); err == nil {
+		t.Fatalf("aggregated signatures of different modes")
+	}
+
+	// Rogue key pk'=x*G1-pk cancels the committee on a repeated message, so that x*H(msg) verifies
+	rogue := &PublicKey{
+		pg1: bls.NewG1().Sub(bls.NewG1().New(), g1FixedBase().mul(sk.fr), pubkey.pg1),
+	}
+	forged := (*Signature)(sk.SignShare(msg))
+	if AggregateVerify([]*PublicKey{pubkey, rogue}, [][]byte{msg, msg}, forged) {
+		t.Fatalf("verified a rogue key aggregate")
+	}
+}
+
+func TestAggregateVerifyWithContext(t *testing.T) {
+	sks := make([]*PrivateKey, 3)
+	pks := make([]*PublicKey, 3)
+	for i := 0; i < 3; i++ {
+		sks[i] = &PrivateKey{
+			fr: RandScalar(),
+		}
+		pks[i] = sks[i].GetPublicKey()
+	}
+
+	// Basic scheme rejects repeated messages
+	basic, _ := NewSigningContext(MinSigSize, BasicScheme)
+	msgs := [][]byte{[]byte("a"), []byte("b"), []byte("a")}
+	sigs := make([]*Signature, 3)
+	for i := 0; i < 3; i++ {
+		share, _ := sks[i].SignShareWithContext(basic, msgs[i])
+		sigs[i] = (*Signature)(share)
+	}
+	aggregated, _ := AggregateSignatures(sigs)
+	if AggregateVerifyWithContext(basic, pks, msgs, aggregated) {
+		t.Fatalf("verified repeated messages in basic scheme")
+	}
+
+	// Augmentation scheme allows them
+	aug, _ := NewSigningContext(MinSigSize, AugScheme)
+	for i := 0; i < 3; i++ {
+		share, _ := sks[i].SignShareWithContext(aug, msgs[i])
+		sigs[i] = (*Signature)(share)
+	}
+	aggregated, _ = AggregateSignatures(sigs)
+	if !AggregateVerifyWithContext(aug, pks, msgs, aggregated) {
+		t.Fatalf("invalid aggregated signature")
+	}
+	if FastAggregateVerifyWithContext(aug, pks, nil, msgs[0], aggregated) {
+		t.Fatalf("fast aggregate verified in augmentation scheme")
+	}
+}
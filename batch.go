@@ -0,0 +1,151 @@
+package tpke
+
+import (
+	"encoding/binary"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+var BatchDomain = []byte("TPKE_BATCH_BLS12381G2_XMD:SHA-256_SSWU_RO_")
+var batchMaskDomain = []byte("TPKE_BATCH_MASK_BLS12381G1_XMD:SHA-256_SSWU_RO_")
+var batchByteSize = 8
+
+// BatchCipherText is bound to a batch id, all ciphertexts of a batch are opened by one share per participant.
+// Be aware that releasing the shares of a batch opens every ciphertext ever encrypted to that batch id.
+type BatchCipherText struct {
+	batch uint64
+	cMsg  *bls.PointG1
+	bigR  *bls.PointG1
+}
+
+func (ct *BatchCipherText) Batch() uint64 {
+	return ct.batch
+}
+
+func (ct *BatchCipherText) ToBytes() []byte {
+	out := make([]byte, batchByteSize+2*fpByteSize)
+	g1 := bls.NewG1()
+	binary.BigEndian.PutUint64(out[:batchByteSize], ct.batch)
+	copy(out[batchByteSize:batchByteSize+fpByteSize], g1.ToCompressed(ct.cMsg))
+	copy(out[batchByteSize+fpByteSize:], g1.ToCompressed(ct.bigR))
+	return out
+}
+
+func BytesToBatchCipherText(b []byte) (*BatchCipherText, error) {
+	if len(b) != batchByteSize+2*fpByteSize {
+		return nil, NewTPKECiphertextError()
+	}
+	g1 := bls.NewG1()
+	cMsg, err := g1.FromCompressed(b[batchByteSize : batchByteSize+fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	bigR, err := g1.FromCompressed(b[batchByteSize+fpByteSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &BatchCipherText{
+		batch: binary.BigEndian.Uint64(b[:batchByteSize]),
+		cMsg:  cMsg,
+		bigR:  bigR,
+	}, nil
+}
+
+// BatchDecryptionShare has a constant size no matter how many ciphertexts are in the batch
+type BatchDecryptionShare struct {
+	pg2 *bls.PointG2
+}
+
+func (s *BatchDecryptionShare) ToBytes() []byte {
+	return bls.NewG2().ToCompressed(s.pg2)
+}
+
+func BytesToBatchDecryptionShare(b []byte) (*BatchDecryptionShare, error) {
+	pg2, err := bls.NewG2().FromCompressed(b)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchDecryptionShare{
+		pg2: pg2,
+	}, nil
+}
+
+func (pk *PublicKey) EncryptBatch(msg *bls.PointG1, batch uint64) *BatchCipherText {
+	r := RandScalar()
+
+	// C=M+H(e(rpk,H(batch))), R=rG1
+	g1 := bls.NewG1()
+	bigR := g1FixedBase().mul(r)
+	rpk := pk.mul(r)
+	pairing := bls.NewEngine()
+	mask := batchMask(pairing.AddPair(rpk, batchHash(batch)).Result())
+
+	return &BatchCipherText{
+		batch: batch,
+		cMsg:  g1.Add(g1.New(), msg, mask),
+		bigR:  bigR,
+	}
+}
+
+func EncryptBatch(msgs []*bls.PointG1, batch uint64, pub *PublicKey) []*BatchCipherText {
+	results := make([]*BatchCipherText, len(msgs))
+	for i := 0; i < len(msgs); i++ {
+		results[i] = pub.EncryptBatch(msgs[i], batch)
+	}
+	return results
+}
+
+func (sk *PrivateKey) BatchDecryptShare(batch uint64) *BatchDecryptionShare {
+	// S=H(batch)*sk
+	g2 := bls.NewG2()
+	pg2 := g2.New()
+	g2.MulScalar(pg2, batchHash(batch), sk.fr)
+	return &BatchDecryptionShare{
+		pg2: pg2,
+	}
+}
+
+// DecryptBatch combines one share per participant into the batch key, and opens all ciphertexts with it
+func DecryptBatch(cts []*BatchCipherText, batch uint64, inputs map[int]*BatchDecryptionShare, pub *PublicKey, threshold int, scaler int) ([]*bls.PointG1, error) {
+	for i := 0; i < len(cts); i++ {
+		if cts[i].batch != batch {
+			return nil, NewTPKECiphertextError()
+		}
+	}
+	shares := make(map[int]*SignatureShare)
+	for index, v := range inputs {
+		shares[index] = &SignatureShare{
+			pg2: v.pg2,
+		}
+	}
+	hash := batchHash(batch)
+	key, err := aggregateAndVerify(threshold, shares, scaler, func(sig *Signature) bool {
+		// e(pk,H(batch))==e(G1,key)
+		return pairingCheck(pub.pg1, hash, &bls.G1One, sig.pg2)
+	})
+	if err != nil {
+		return nil, NewTPKEDecryptionError()
+	}
+
+	// M=C-H(e(R,key))
+	g1 := bls.NewG1()
+	results := make([]*bls.PointG1, len(cts))
+	for i := 0; i < len(cts); i++ {
+		pairing := bls.NewEngine()
+		mask := batchMask(pairing.AddPair(cts[i].bigR, key.pg2).Result())
+		results[i] = g1.Sub(g1.New(), cts[i].cMsg, mask)
+	}
+	return results, nil
+}
+
+func batchHash(batch uint64) *bls.PointG2 {
+	id := make([]byte, batchByteSize)
+	binary.BigEndian.PutUint64(id, batch)
+	g2Hash, _ := bls.NewG2().HashToCurve(id, BatchDomain)
+	return g2Hash
+}
+
+func batchMask(g *bls.E) *bls.PointG1 {
+	mask, _ := bls.NewG1().HashToCurve(bls.NewGT().ToBytes(g), batchMaskDomain)
+	return mask
+}
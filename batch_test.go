@@ -0,0 +1,58 @@
+package tpke
+
+import (
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestBatchDecryption(t *testing.T) {
+	size := 7
+	threshold := 5
+	amount := 100
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+
+	// Encrypt
+	batch := uint64(42)
+	msgs := make([]*bls.PointG1, amount)
+	for i := 0; i < amount; i++ {
+		msgs[i] = RandPG1()
+	}
+	cts := EncryptBatch(msgs, batch, pubkey)
+	ct, err := BytesToBatchCipherText(cts[0].ToBytes())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	cts[0] = ct
+
+	// One share per participant
+	shares := make(map[int]*BatchDecryptionShare)
+	for i := 1; i <= size; i++ {
+		shares[i] = prvkeys[i].BatchDecryptShare(batch)
+	}
+
+	// Put a wrong share
+	shares[2] = prvkeys[2].BatchDecryptShare(batch + 1)
+
+	// Decrypt
+	results, err := DecryptBatch(cts, batch, shares, pubkey, threshold, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i := 0; i < amount; i++ {
+		if !bls.NewG1().Equal(msgs[i], results[i]) {
+			t.Fatalf("decryption failed.")
+		}
+	}
+
+	// Ciphertexts of another batch are rejected
+	if _, err := DecryptBatch(EncryptBatch(msgs[:1], batch+1, pubkey), batch, shares, pubkey, threshold, dkg.GetScaler()); err == nil {
+		t.Fatalf("decrypted a ciphertext of another batch.")
+	}
+}
@@ -0,0 +1,308 @@
+package tpke
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+)
+
+var roundByteSize = 8
+
+// Beacon rounds are signed under their own tags, so that a beacon is never a valid signature of another purpose
+var BeaconDomain = []byte("TPKE_BEACON_BLS12381G2_XMD:SHA-256_SSWU_RO_")
+var BeaconDomainG1 = []byte("TPKE_BEACON_BLS12381G1_XMD:SHA-256_SSWU_RO_")
+
+type BeaconMode int
+
+const (
+	ChainedBeacon   BeaconMode = iota // Round message includes the signature of the previous round
+	UnchainedBeacon                   // Round message only depends on the round number
+)
+
+// Beacon is the output of a round, the randomness is the hash of the threshold signature
+type Beacon struct {
+	round   uint64
+	prevSig []byte // Only in chained mode
+	sig     *Signature
+}
+
+func (b *Beacon) Round() uint64 {
+	return b.round
+}
+
+func (b *Beacon) PreviousSignature() []byte {
+	return b.prevSig
+}
+
+func (b *Beacon) Signature() *Signature {
+	return b.sig
+}
+
+// Randomness=H(sig)
+func (b *Beacon) Randomness() []byte {
+	hash := sha256.Sum256(b.sig.ToBytes())
+	return hash[:]
+}
+
+// ToBytes encodes the round, the length of the previous signature in 4 bytes, the previous signature and the signature
+func (b *Beacon) ToBytes() []byte {
+	sig := b.sig.ToBytes()
+	out := make([]byte, roundByteSize+4+len(b.prevSig)+len(sig))
+	binary.BigEndian.PutUint64(out[:roundByteSize], b.round)
+	binary.BigEndian.PutUint32(out[roundByteSize:roundByteSize+4], uint32(len(b.prevSig)))
+	copy(out[roundByteSize+4:], b.prevSig)
+	copy(out[roundByteSize+4+len(b.prevSig):], sig)
+	return out
+}
+
+func BytesToBeacon(b []byte) (*Beacon, error) {
+	if len(b) < roundByteSize+4 {
+		return nil, NewBeaconStorageError()
+	}
+	prevLen := uint64(binary.BigEndian.Uint32(b[roundByteSize : roundByteSize+4]))
+	if uint64(len(b)) < uint64(roundByteSize+4)+prevLen {
+		return nil, NewBeaconStorageError()
+	}
+	sig, err := BytesToSig(b[roundByteSize+4+int(prevLen):])
+	if err != nil {
+		return nil, err
+	}
+	var prevSig []byte
+	if prevLen > 0 {
+		prevSig = make([]byte, prevLen)
+		copy(prevSig, b[roundByteSize+4:])
+	}
+	return &Beacon{
+		round:   binary.BigEndian.Uint64(b[:roundByteSize]),
+		prevSig: prevSig,
+		sig:     sig,
+	}, nil
+}
+
+// BeaconMessage is H(prev_sig||round) in chained mode, and H(round) in unchained mode
+func BeaconMessage(mode BeaconMode, round uint64, prevSig []byte) []byte {
+	h := sha256.New()
+	if mode == ChainedBeacon {
+		h.Write(prevSig)
+	}
+	r := make([]byte, roundByteSize)
+	binary.BigEndian.PutUint64(r, round)
+	h.Write(r)
+	return h.Sum(nil)
+}
+
+// Round messages are distinct, so the basic scheme is enough
+func beaconSigningContext(mode SignatureMode) *SigningContext {
+	dst := BeaconDomain
+	if mode == MinSigSize {
+		dst = BeaconDomainG1
+	}
+	return &SigningContext{
+		mode:   mode,
+		scheme: BasicScheme,
+		dst:    dst,
+	}
+}
+
+// VerifyBeacon checks a single beacon against the global public key, with no need of its history
+func VerifyBeacon(pub *PublicKey, mode BeaconMode, b *Beacon) error {
+	if b.round == 0 {
+		return NewBeaconRoundError()
+	}
+	if mode == UnchainedBeacon && len(b.prevSig) > 0 {
+		return NewBeaconSignatureError()
+	}
+	if !pub.VerifySigWithContext(beaconSigningContext(b.sig.Mode()), BeaconMessage(mode, b.round, b.prevSig), b.sig) {
+		return NewBeaconSignatureError()
+	}
+	return nil
+}
+
+// BeaconSchedule maps time to rounds, round 1 starts at genesis and a new round starts every period
+type BeaconSchedule struct {
+	genesis time.Time
+	period  time.Duration
+}
+
+func NewBeaconSchedule(genesis time.Time, period time.Duration) (*BeaconSchedule, error) {
+	if period <= 0 {
+		return nil, NewBeaconPeriodError()
+	}
+	return &BeaconSchedule{
+		genesis: genesis,
+		period:  period,
+	}, nil
+}
+
+// RoundAt returns 0 before genesis
+func (s *BeaconSchedule) RoundAt(t time.Time) uint64 {
+	if t.Before(s.genesis) {
+		return 0
+	}
+	return uint64(t.Sub(s.genesis)/s.period) + 1
+}
+
+func (s *BeaconSchedule) TimeOf(round uint64) time.Time {
+	if round == 0 {
+		return s.genesis
+	}
+	return s.genesis.Add(time.Duration(round-1) * s.period)
+}
+
+// BeaconChain produces, verifies and stores the rounds of a group
+type BeaconChain struct {
+	pub      *PublicKey
+	mode     BeaconMode
+	sigMode  SignatureMode
+	seed     []byte // Previous signature of round 1 in chained mode
+	schedule *BeaconSchedule
+	store    BeaconStore
+}
+
+func NewBeaconChain(pub *PublicKey, mode BeaconMode, sigMode SignatureMode, seed []byte, schedule *BeaconSchedule, store BeaconStore) *BeaconChain {
+	return &BeaconChain{
+		pub:      pub,
+		mode:     mode,
+		sigMode:  sigMode,
+		seed:     seed,
+		schedule: schedule,
+		store:    store,
+	}
+}
+
+func (c *BeaconChain) Schedule() *BeaconSchedule {
+	return c.schedule
+}
+
+// LastRound returns 0 if no round is stored
+func (c *BeaconChain) LastRound() (uint64, error) {
+	last, err := c.store.Last()
+	if err != nil || last == nil {
+		return 0, err
+	}
+	return last.round, nil
+}
+
+func (c *BeaconChain) Get(round uint64) (*Beacon, error) {
+	return c.store.Get(round)
+}
+
+func (c *BeaconChain) previousSignature(round uint64) ([]byte, error) {
+	if c.mode == UnchainedBeacon {
+		return nil, nil
+	}
+	if round == 1 {
+		return c.seed, nil
+	}
+	prev, err := c.store.Get(round - 1)
+	if err != nil {
+		return nil, err
+	}
+	return prev.sig.ToBytes(), nil
+}
+
+// Message returns what participants sign in a round, chained rounds need the previous round stored
+func (c *BeaconChain) Message(round uint64) ([]byte, error) {
+	if round == 0 {
+		return nil, NewBeaconRoundError()
+	}
+	prevSig, err := c.previousSignature(round)
+	if err != nil {
+		return nil, err
+	}
+	return BeaconMessage(c.mode, round, prevSig), nil
+}
+
+func (c *BeaconChain) SignShare(sk *PrivateKey, round uint64) (*SignatureShare, error) {
+	msg, err := c.Message(round)
+	if err != nil {
+		return nil, err
+	}
+	return sk.SignShareWithContext(beaconSigningContext(c.sigMode), msg)
+}
+
+// Aggregate combines the shares of a round and appends the beacon to the chain.
+// Like AggregateAndVerifySigWithContext, indices of invalid shares are returned beside the beacon.
+func (c *BeaconChain) Aggregate(round uint64, vks map[int]*PublicKey, inputs map[int]*SignatureShare, threshold int, scaler int) (*Beacon, []int, error) {
+	prevSig, err := c.previousSignature(round)
+	if err != nil {
+		return nil, nil, err
+	}
+	msg := BeaconMessage(c.mode, round, prevSig)
+	sig, invalid, err := AggregateAndVerifySigWithContext(beaconSigningContext(c.sigMode), c.pub, vks, msg, threshold, inputs, scaler)
+	if err != nil {
+		return nil, invalid, err
+	}
+	b := &Beacon{
+		round:   round,
+		prevSig: prevSig,
+		sig:     sig,
+	}
+	if err := c.Append(b); err != nil {
+		return nil, invalid, err
+	}
+	return b, invalid, nil
+}
+
+// VerifyRound checks a beacon and its link to the stored history
+func (c *BeaconChain) VerifyRound(b *Beacon) error {
+	if b.sig.Mode() != c.sigMode {
+		return NewBeaconSignatureError()
+	}
+	if err := VerifyBeacon(c.pub, c.mode, b); err != nil {
+		return err
+	}
+	prevSig, err := c.previousSignature(b.round)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(prevSig, b.prevSig) {
+		return NewBeaconSignatureError()
+	}
+	return nil
+}
+
+// Append stores the next round, chained rounds must be consecutive
+func (c *BeaconChain) Append(b *Beacon) error {
+	last, err := c.LastRound()
+	if err != nil {
+		return err
+	}
+	if b.round <= last || (c.mode == ChainedBeacon && b.round != last+1) {
+		return NewBeaconRoundError()
+	}
+	if err := c.VerifyRound(b); err != nil {
+		return err
+	}
+	return c.store.Put(b)
+}
+
+// Sync fetches and verifies missing rounds up to the target, e.g. from another node.
+// It returns the number of appended rounds, rounds before an error are kept.
+func (c *BeaconChain) Sync(to uint64, fetch func(round uint64) (*Beacon, error)) (int, error) {
+	last, err := c.LastRound()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for round := last + 1; round <= to; round++ {
+		b, err := fetch(round)
+		if err != nil {
+			return count, err
+		}
+		if b.round != round {
+			return count, NewBeaconRoundError()
+		}
+		if err := c.Append(b); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// CatchUp syncs to the latest round expected by the schedule
+func (c *BeaconChain) CatchUp(now time.Time, fetch func(round uint64) (*Beacon, error)) (int, error) {
+	return c.Sync(c.schedule.RoundAt(now), fetch)
+}
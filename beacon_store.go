@@ -0,0 +1,176 @@
+package tpke
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// BeaconStore keeps past rounds, Last returns nil if nothing is stored
+type BeaconStore interface {
+	Put(b *Beacon) error
+	Get(round uint64) (*Beacon, error)
+	Last() (*Beacon, error)
+}
+
+type MemoryBeaconStore struct {
+	lock    sync.RWMutex
+	beacons map[uint64]*Beacon
+	last    uint64
+}
+
+func NewMemoryBeaconStore() *MemoryBeaconStore {
+	return &MemoryBeaconStore{
+		beacons: make(map[uint64]*Beacon),
+	}
+}
+
+func (s *MemoryBeaconStore) Put(b *Beacon) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.beacons[b.round] = b
+	if b.round > s.last {
+		s.last = b.round
+	}
+	return nil
+}
+
+func (s *MemoryBeaconStore) Get(round uint64) (*Beacon, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	b, ok := s.beacons[round]
+	if !ok {
+		return nil, NewBeaconNotFoundError()
+	}
+	return b, nil
+}
+
+func (s *MemoryBeaconStore) Last() (*Beacon, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.last == 0 {
+		return nil, nil
+	}
+	return s.beacons[s.last], nil
+}
+
+// FileBeaconStore writes every round into its own file under a directory
+type FileBeaconStore struct {
+	lock sync.RWMutex
+	dir  string
+	last uint64
+}
+
+// NewFileBeaconStore opens or creates the directory and recovers the last round
+func NewFileBeaconStore(dir string) (*FileBeaconStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileBeaconStore{
+		dir: dir,
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".beacon" {
+			continue
+		}
+		round, err := strconv.ParseUint(entry.Name()[:len(entry.Name())-len(".beacon")], 10, 64)
+		if err != nil {
+			continue
+		}
+		if round > s.last {
+			s.last = round
+		}
+	}
+	return s, nil
+}
+
+func (s *FileBeaconStore) path(round uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d.beacon", round))
+}
+
+func (s *FileBeaconStore) Put(b *Beacon) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// Write and sync a temporary file first, then rename it and sync the directory, so that a crash never leaves a broken round
+	tmp := s.path(b.round) + ".tmp"
+	if err := writeFileSync(tmp, b.ToBytes()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path(b.round)); err != nil {
+		return err
+	}
+	if err := syncDir(s.dir); err != nil {
+		return err
+	}
+	if b.round > s.last {
+		s.last = b.round
+	}
+	return nil
+}
+
+func (s *FileBeaconStore) Get(round uint64) (*Beacon, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.read(round)
+}
+
+func (s *FileBeaconStore) Last() (*Beacon, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.last == 0 {
+		return nil, nil
+	}
+	return s.read(s.last)
+}
+
+func (s *FileBeaconStore) read(round uint64) (*Beacon, error) {
+	data, err := os.ReadFile(s.path(round))
+	if os.IsNotExist(err) {
+		return nil, NewBeaconNotFoundError()
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := BytesToBeacon(data)
+	if err != nil {
+		return nil, err
+	}
+	if b.round != round {
+		return nil, NewBeaconStorageError()
+	}
+	return b, nil
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
@@ -0,0 +1,151 @@
+package tpke
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestBeacon(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+	vks := dkg.PublishVerificationKeys()
+
+	genesis := time.Now()
+	schedule, err := NewBeaconSchedule(genesis, 3*time.Second)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err := NewBeaconSchedule(genesis, 0); err == nil {
+		t.Fatalf("accepted a zero period")
+	}
+	if schedule.RoundAt(genesis.Add(-time.Second)) != 0 || schedule.RoundAt(genesis) != 1 || schedule.RoundAt(genesis.Add(7*time.Second)) != 3 {
+		t.Fatalf("unexpected round")
+	}
+	if !schedule.TimeOf(3).Equal(genesis.Add(6 * time.Second)) {
+		t.Fatalf("unexpected time")
+	}
+
+	// Produce rounds
+	seed := []byte("genesis seed")
+	store, err := NewFileBeaconStore(t.TempDir())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	chain := NewBeaconChain(pubkey, ChainedBeacon, MinPubKeySize, seed, schedule, store)
+	for round := uint64(1); round <= 3; round++ {
+		shares := make(map[int]*SignatureShare)
+		for i := 1; i <= size; i++ {
+			if shares[i], err = chain.SignShare(prvkeys[i], round); err != nil {
+				t.Fatalf(err.Error())
+			}
+		}
+		b, _, err := chain.Aggregate(round, vks, shares, threshold, dkg.GetScaler())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := VerifyBeacon(pubkey, ChainedBeacon, b); err != nil {
+			t.Fatalf(err.Error())
+		}
+		// Beacons are not signatures of the default context
+		if msg, _ := chain.Message(round); pubkey.VerifySig(msg, b.sig) {
+			t.Fatalf("beacon verified in the default context")
+		}
+	}
+	if _, err := chain.SignShare(prvkeys[1], 5); err == nil {
+		t.Fatalf("signed a round without its previous round")
+	}
+
+	// Reopen storage
+	store, err = NewFileBeaconStore(store.dir)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	last, err := store.Last()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if last.Round() != 3 {
+		t.Fatalf("unexpected last round %d", last.Round())
+	}
+	b, err := BytesToBeacon(last.ToBytes())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bytes.Equal(b.Randomness(), last.Randomness()) {
+		t.Fatalf("inconsistent randomness")
+	}
+
+	// A seed longer than 255 bytes is kept
+	long := &Beacon{
+		round:   1,
+		prevSig: bytes.Repeat([]byte{1}, 300),
+		sig:     last.sig,
+	}
+	if b, err = BytesToBeacon(long.ToBytes()); err != nil || !bytes.Equal(b.prevSig, long.prevSig) {
+		t.Fatalf("inconsistent previous signature")
+	}
+
+	// Catch up from another node
+	follower := NewBeaconChain(pubkey, ChainedBeacon, MinPubKeySize, seed, schedule, NewMemoryBeaconStore())
+	count, err := follower.CatchUp(genesis.Add(7*time.Second), chain.Get)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if count != 3 {
+		t.Fatalf("unexpected count %d", count)
+	}
+
+	// Forged history
+	forged := NewBeaconChain(pubkey, ChainedBeacon, MinPubKeySize, []byte("another seed"), schedule, NewMemoryBeaconStore())
+	if _, err := forged.Sync(3, chain.Get); err == nil {
+		t.Fatalf("synced a chain of another seed")
+	}
+	if err := VerifyBeacon(pubkey, UnchainedBeacon, last); err == nil {
+		t.Fatalf("verified a chained beacon as unchained")
+	}
+}
+
+func TestUnchainedBeacon(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKGWithMode(size, threshold, MinSigSize)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+	vks := dkg.PublishVerificationKeys()
+
+	// Rounds can be skipped without previous signatures
+	schedule, err := NewBeaconSchedule(time.Now(), time.Second)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	chain := NewBeaconChain(pubkey, UnchainedBeacon, MinSigSize, nil, schedule, NewMemoryBeaconStore())
+	for _, round := range []uint64{2, 5} {
+		shares := make(map[int]*SignatureShare)
+		for i := 1; i <= size; i++ {
+			shares[i], _ = chain.SignShare(prvkeys[i], round)
+		}
+		b, _, err := chain.Aggregate(round, vks, shares, threshold, dkg.GetScaler())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := VerifyBeacon(pubkey, UnchainedBeacon, b); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	b, _ := chain.Get(5)
+	if err := chain.Append(b); err == nil {
+		t.Fatalf("appended a round twice")
+	}
+}
@@ -0,0 +1,122 @@
+package tpke
+
+import (
+	bls "github.com/kilic/bls12-381"
+)
+
+// BlindedMessage is b*H(msg), participants sign it without learning msg
+type BlindedMessage struct {
+	pg1 *bls.PointG1 // Only in min-sig mode
+	pg2 *bls.PointG2
+}
+
+// BlindingFactor is kept by the user to unblind the signature
+type BlindingFactor struct {
+	fr *bls.Fr
+}
+
+func (bm *BlindedMessage) Mode() SignatureMode {
+	if bm.pg1 != nil {
+		return MinSigSize
+	}
+	return MinPubKeySize
+}
+
+func (bm *BlindedMessage) ToBytes() []byte {
+	if bm.Mode() == MinSigSize {
+		return bls.NewG1().ToCompressed(bm.pg1)
+	}
+	return bls.NewG2().ToCompressed(bm.pg2)
+}
+
+// Decoded points are checked to be in the subgroup, so that no share leaks the private key out of it
+func BytesToBlindedMessage(b []byte) (*BlindedMessage, error) {
+	sig, err := BytesToSig(b)
+	if err != nil {
+		return nil, err
+	}
+	return &BlindedMessage{
+		pg1: sig.pg1,
+		pg2: sig.pg2,
+	}, nil
+}
+
+func Blind(msg []byte, mode SignatureMode) (*BlindedMessage, *BlindingFactor) {
+	bm, factor, _ := BlindWithContext(defaultSigningContext(mode), nil, msg)
+	return bm, factor
+}
+
+// BlindWithContext hashes msg in the context, the global public key is needed by augmentation scheme
+func BlindWithContext(ctx *SigningContext, pub *PublicKey, msg []byte) (*BlindedMessage, *BlindingFactor, error) {
+	if ctx.scheme == AugScheme && ctx.key == nil {
+		if pub == nil {
+			return nil, nil, NewSigContextError()
+		}
+		ctx = ctx.WithKey(pub)
+	}
+	b := RandScalar()
+	if ctx.mode == MinSigSize {
+		g1Hash, err := ctx.hashToG1(nil, msg)
+		if err != nil {
+			return nil, nil, err
+		}
+		g1 := bls.NewG1()
+		return &BlindedMessage{
+			pg1: g1.MulScalar(g1.New(), g1Hash, b),
+		}, &BlindingFactor{
+			fr: b,
+		}, nil
+	}
+	g2 := bls.NewG2()
+	return &BlindedMessage{
+		pg2: g2.MulScalar(g2.New(), ctx.hashToG2(nil, msg), b),
+	}, &BlindingFactor{
+		fr: b,
+	}, nil
+}
+
+// SignBlindedShare returns S=sk*M', which is checked by VerifyBlindedShare like any other share
+func (sk *PrivateKey) SignBlindedShare(bm *BlindedMessage) (*SignatureShare, error) {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	if bm.Mode() == MinSigSize {
+		if g1.IsZero(bm.pg1) {
+			return nil, NewSigBlindError()
+		}
+		return &SignatureShare{
+			pg1: g1.MulScalar(g1.New(), bm.pg1, sk.fr),
+		}, nil
+	}
+	if g2.IsZero(bm.pg2) {
+		return nil, NewSigBlindError()
+	}
+	return &SignatureShare{
+		pg2: g2.MulScalar(g2.New(), bm.pg2, sk.fr),
+	}, nil
+}
+
+func (pk *PublicKey) VerifyBlindedShare(bm *BlindedMessage, share *SignatureShare) bool {
+	if share.Mode() != bm.Mode() {
+		return false
+	}
+	return verifySigShareOnce(pk, bm.pg1, bm.pg2, share)
+}
+
+// AggregateBlindedShares works like AggregateAndVerifySigWithKeys, and returns a blinded signature for Unblind
+func AggregateBlindedShares(pk *PublicKey, vks map[int]*PublicKey, bm *BlindedMessage, threshold int, inputs map[int]*SignatureShare, scaler int) (*Signature, []int, error) {
+	return aggregateAndVerifyHashed(pk, vks, bm.pg1, bm.pg2, bm.Mode(), threshold, inputs, scaler)
+}
+
+// Unblind computes S=S'/b, which is an ordinary signature on msg
+func Unblind(sig *Signature, factor *BlindingFactor) *Signature {
+	inverse := bls.NewFr()
+	inverse.Inverse(factor.fr)
+	if sig.Mode() == MinSigSize {
+		g1 := bls.NewG1()
+		return &Signature{
+			pg1: g1.MulScalar(g1.New(), sig.pg1, inverse),
+		}
+	}
+	g2 := bls.NewG2()
+	return NewSignature(g2.MulScalar(g2.New(), sig.pg2, inverse))
+}
@@ -0,0 +1,62 @@
+package tpke
+
+import (
+	"testing"
+)
+
+func TestBlindSignature(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKGWithMode(size, threshold, MinSigSize)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+	vks := dkg.PublishVerificationKeys()
+
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+	for _, mode := range []SignatureMode{MinPubKeySize, MinSigSize} {
+		// User blinds the message
+		bm, factor := Blind(msg, mode)
+		bm, err := BytesToBlindedMessage(bm.ToBytes())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		// Committee signs the blinded message, with a wrong share
+		shares := make(map[int]*SignatureShare)
+		for i := 1; i <= size; i++ {
+			if shares[i], err = prvkeys[i].SignBlindedShare(bm); err != nil {
+				t.Fatalf(err.Error())
+			}
+			if !vks[i].VerifyBlindedShare(bm, shares[i]) {
+				t.Fatalf("invalid blinded share")
+			}
+		}
+		shares[4] = shares[5]
+		blinded, invalid, err := AggregateBlindedShares(pubkey, vks, bm, threshold, shares, dkg.GetScaler())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if len(invalid) != 1 || invalid[0] != 4 {
+			t.Fatalf("unexpected invalid shares %v", invalid)
+		}
+		if pubkey.VerifySig(msg, blinded) {
+			t.Fatalf("blinded signature verified")
+		}
+
+		// User unblinds the signature
+		sig := Unblind(blinded, factor)
+		if !pubkey.VerifySig(msg, sig) {
+			t.Fatalf("invalid signature")
+		}
+	}
+
+	// Augmentation needs the global public key
+	aug, _ := NewSigningContext(MinPubKeySize, AugScheme)
+	if _, _, err := BlindWithContext(aug, nil, msg); err == nil {
+		t.Fatalf("blinded without a key")
+	}
+}
@@ -0,0 +1,153 @@
+package tpke
+
+import (
+	"fmt"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+type SignatureScheme int
+
+const (
+	BasicScheme SignatureScheme = iota // Messages of an aggregate must be distinct
+	AugScheme                          // Messages are prefixed with the signer public key
+	PopScheme                          // Public keys come with proofs of possession
+)
+
+// SigningContext separates signatures of different purposes, e.g. consensus votes and randomness
+type SigningContext struct {
+	mode   SignatureMode
+	scheme SignatureScheme
+	dst    []byte
+	key    *PublicKey // Bound key for augmentation, e.g. the global public key
+}
+
+// NewSigningContext takes the standard ciphersuite of the scheme, e.g. BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_
+func NewSigningContext(mode SignatureMode, scheme SignatureScheme) (*SigningContext, error) {
+	id, err := ciphersuiteID(mode, scheme)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningContext{
+		mode:   mode,
+		scheme: scheme,
+		dst:    []byte(id),
+	}, nil
+}
+
+// NewSigningContextWithDST takes an application specific tag, which should not collide with other contexts
+func NewSigningContextWithDST(mode SignatureMode, scheme SignatureScheme, dst []byte) (*SigningContext, error) {
+	if _, err := ciphersuiteID(mode, scheme); err != nil {
+		return nil, err
+	}
+	// Hash to curve takes tags of 1 to 255 bytes
+	if len(dst) < 1 || len(dst) > 255 {
+		return nil, NewSigContextError()
+	}
+	return &SigningContext{
+		mode:   mode,
+		scheme: scheme,
+		dst:    append([]byte{}, dst...),
+	}, nil
+}
+
+// Default context keeps the behaviour of SignShare and VerifySig, with tags Domain and DomainG1
+func defaultSigningContext(mode SignatureMode) *SigningContext {
+	dst := Domain
+	if mode == MinSigSize {
+		dst = DomainG1
+	}
+	return &SigningContext{
+		mode:   mode,
+		scheme: PopScheme,
+		dst:    dst,
+	}
+}
+
+func ciphersuiteID(mode SignatureMode, scheme SignatureScheme) (string, error) {
+	group := ""
+	switch mode {
+	case MinPubKeySize:
+		group = "G2"
+	case MinSigSize:
+		group = "G1"
+	default:
+		return "", NewSigContextError()
+	}
+	tag := ""
+	switch scheme {
+	case BasicScheme:
+		tag = "NUL"
+	case AugScheme:
+		tag = "AUG"
+	case PopScheme:
+		tag = "POP"
+	default:
+		return "", NewSigContextError()
+	}
+	return fmt.Sprintf("BLS_SIG_BLS12381%s_XMD:SHA-256_SSWU_RO_%s_", group, tag), nil
+}
+
+func (c *SigningContext) Mode() SignatureMode {
+	return c.mode
+}
+
+func (c *SigningContext) Scheme() SignatureScheme {
+	return c.scheme
+}
+
+func (c *SigningContext) DST() []byte {
+	return append([]byte{}, c.dst...)
+}
+
+// WithKey binds the augmentation to a key, so that all shares of a threshold signature are prefixed with the global public key
+func (c *SigningContext) WithKey(pk *PublicKey) *SigningContext {
+	return &SigningContext{
+		mode:   c.mode,
+		scheme: c.scheme,
+		dst:    c.dst,
+		key:    pk,
+	}
+}
+
+// Augmented message is pk||msg, where pk is the bound key or the signer key.
+// In min-sig mode the prefix is the G2 form, and a key without it fails rather than signing msg unprefixed.
+func (c *SigningContext) augment(signer *PublicKey, msg []byte) ([]byte, error) {
+	if c.scheme != AugScheme {
+		return msg, nil
+	}
+	pk := signer
+	if c.key != nil {
+		pk = c.key
+	}
+	var prefix []byte
+	if c.mode == MinSigSize {
+		if pk.pg2 == nil {
+			return nil, NewSigContextKeyError()
+		}
+		prefix = bls.NewG2().ToCompressed(pk.pg2)
+	} else {
+		prefix = bls.NewG1().ToCompressed(pk.pg1)
+	}
+	out := make([]byte, len(prefix)+len(msg))
+	copy(out, prefix)
+	copy(out[len(prefix):], msg)
+	return out, nil
+}
+
+// Hash message to the signature group, G1 in min-sig mode and G2 otherwise
+func (c *SigningContext) hashToG1(signer *PublicKey, msg []byte) (*bls.PointG1, error) {
+	augmented, err := c.augment(signer, msg)
+	if err != nil {
+		return nil, err
+	}
+	g1Hash, _ := bls.NewG1().HashToCurve(augmented, c.dst)
+	return g1Hash, nil
+}
+
+// Keys always have a G1 form, so augmentation of G2 hashes never fails
+func (c *SigningContext) hashToG2(signer *PublicKey, msg []byte) *bls.PointG2 {
+	augmented, _ := c.augment(signer, msg)
+	g2Hash, _ := bls.NewG2().HashToCurve(augmented, c.dst)
+	return g2Hash
+}
@@ -0,0 +1,136 @@
+package tpke
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSigningContext(t *testing.T) {
+	ctx, err := NewSigningContext(MinPubKeySize, PopScheme)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bytes.Equal(ctx.DST(), Domain) {
+		t.Fatalf("unexpected dst %s", ctx.DST())
+	}
+	ctx, err = NewSigningContext(MinSigSize, BasicScheme)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if string(ctx.DST()) != "BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_" {
+		t.Fatalf("unexpected dst %s", ctx.DST())
+	}
+	if _, err := NewSigningContextWithDST(MinPubKeySize, BasicScheme, nil); err == nil {
+		t.Fatalf("empty dst accepted")
+	}
+	if _, err := NewSigningContext(MinPubKeySize, SignatureScheme(3)); err == nil {
+		t.Fatalf("unknown scheme accepted")
+	}
+
+	// Signatures of different contexts are not interchangeable
+	sk := &PrivateKey{
+		fr: RandScalar(),
+	}
+	pk := sk.GetPublicKey()
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+	votes, _ := NewSigningContextWithDST(MinPubKeySize, BasicScheme, []byte("TPKE_VOTE_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_"))
+	beacon, _ := NewSigningContextWithDST(MinPubKeySize, BasicScheme, []byte("TPKE_BEACON_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_"))
+	share, err := sk.SignShareWithContext(votes, msg)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !pk.VerifySigShareWithContext(votes, msg, share) {
+		t.Fatalf("invalid signature")
+	}
+	if pk.VerifySigShareWithContext(beacon, msg, share) || pk.VerifySigShare(msg, share) {
+		t.Fatalf("signature verified in another context")
+	}
+
+	// Augmented signature is bound to the signer key
+	aug, _ := NewSigningContext(MinSigSize, AugScheme)
+	share, err = sk.SignShareWithContext(aug, msg)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !pk.VerifySigShareWithContext(aug, msg, share) {
+		t.Fatalf("invalid signature")
+	}
+	other := (&PrivateKey{fr: RandScalar()}).GetPublicKey()
+	if pk.VerifySigShareWithContext(aug.WithKey(other), msg, share) {
+		t.Fatalf("signature verified with another key prefix")
+	}
+
+	// A key without its G2 form is no prefix in min-sig mode
+	if _, err := sk.SignShareWithContext(aug.WithKey(&PublicKey{pg1: other.pg1}), msg); err == nil {
+		t.Fatalf("signed without a key prefix")
+	}
+}
+
+func TestThresholdSignatureWithContext(t *testing.T) {
+	size := 7
+	threshold := 5
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+	for _, mode := range []SignatureMode{MinPubKeySize, MinSigSize} {
+		dkg := NewDKGWithMode(size, threshold, mode)
+		dkg.Prepare()
+		if err := dkg.VerifyPrepare(); err != nil {
+			t.Fatalf(err.Error())
+		}
+		sks := dkg.GetPrivateKeysFromPrepare()
+		pk := dkg.PublishGlobalPublicKey()
+		vks := dkg.PublishVerificationKeys()
+		scaler := dkg.GetScaler()
+
+		// Shares are bound to the global public key
+		unbound, err := NewSigningContext(mode, AugScheme)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		ctx := unbound.WithKey(pk)
+		shares := make(map[int]*SignatureShare)
+		for i := 1; i <= len(sks); i++ {
+			if shares[i], err = sks[i].SignShareWithContext(ctx, msg); err != nil {
+				t.Fatalf(err.Error())
+			}
+			if !vks[i].VerifySigShareWithContext(ctx, msg, shares[i]) {
+				t.Fatalf("invalid signature share")
+			}
+		}
+		sig, _, err := AggregateAndVerifySigWithContext(ctx, pk, vks, msg, threshold, shares, scaler)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !pk.VerifySigWithContext(ctx, msg, sig) {
+			t.Fatalf("invalid signature")
+		}
+		if sig, _, err = AggregateAndVerifySigWithContext(ctx, pk, nil, msg, threshold, shares, scaler); err != nil || !pk.VerifySigWithContext(ctx, msg, sig) {
+			t.Fatalf("invalid signature without verification keys")
+		}
+
+		// Unbound context takes the global public key as the prefix of a full signature
+		if !pk.VerifySigWithContext(unbound, msg, sig) {
+			t.Fatalf("invalid signature")
+		}
+		if pk.VerifySig(msg, sig) {
+			t.Fatalf("signature verified in the default context")
+		}
+
+		// But shares are never combined in it, nor in a context bound to another key
+		if _, _, err := AggregateAndVerifySigWithContext(unbound, pk, vks, msg, threshold, shares, scaler); err == nil {
+			t.Fatalf("aggregated in an unbound context")
+		}
+		if _, _, err := AggregateAndVerifySigWithContext(unbound.WithKey(vks[1]), pk, vks, msg, threshold, shares, scaler); err == nil {
+			t.Fatalf("aggregated in a context bound to another key")
+		}
+
+		// Shares signed under their own keys are invalid
+		for i := 1; i <= len(sks); i++ {
+			if shares[i], err = sks[i].SignShareWithContext(unbound, msg); err != nil {
+				t.Fatalf(err.Error())
+			}
+		}
+		if _, invalid, err := AggregateAndVerifySigWithContext(ctx, pk, vks, msg, threshold, shares, scaler); err == nil || len(invalid) != size {
+			t.Fatalf("aggregated shares signed under their own keys")
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package tpke
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sort"
+
+	bls "github.com/kilic/bls12-381"
+	"github.com/txhsl/tpke/polynomial"
+)
+
+// Shares are a Reed-Solomon codeword, values of a polynomial of degree below threshold at participant indices.
+// Scalar shares are decoded by Berlekamp-Welch, up to (n-threshold)/2 corrupted ones. Shares in the exponent are
+// only searched over, which may miss corrupted ones within that bound.
+
+// Searches over shares in the exponent stop after this many checks, so that corrupted shares cannot make them run for exponential time.
+// Beyond it, shares are checked one by one with verification keys, e.g. by DecryptWithKeys or AggregateAndVerifySigWithKeys.
+var maxDecodingAttempts = 1 << 10
+
+// RecoverSecret decodes scalar shares with Berlekamp-Welch, and returns f(0) with indices of corrupted shares
+func RecoverSecret(shares map[int]*bls.Fr, threshold int) (*bls.Fr, []int, error) {
+	if len(shares) < threshold {
+		return nil, nil, NewDecodingNotEnoughShareError()
+	}
+	xs := make([]int, 0, len(shares))
+	for index := range shares {
+		xs = append(xs, index)
+	}
+	sort.Ints(xs)
+	ys := make([]*bls.Fr, len(xs))
+	for i, x := range xs {
+		ys[i] = shares[x]
+	}
+	poly, errors, err := decodeFr(xs, ys, threshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	invalid := make([]int, len(errors))
+	for i, pos := range errors {
+		invalid[i] = xs[pos]
+	}
+	return poly[0], invalid, nil
+}
+
+// LocateInvalidDecryptionShares tells participants with a corrupted share of any ciphertext, without verification keys.
+// Participants without a share of every ciphertext are invalid too. The search is bounded by maxDecodingAttempts.
+func LocateInvalidDecryptionShares(cts []*CipherText, inputs map[int]([]*DecryptionShare), threshold int) ([]int, error) {
+	invalid := make([]int, 0)
+	indices := make([]int, 0, len(inputs))
+	for index, v := range inputs {
+		if len(v) == len(cts) {
+			indices = append(indices, index)
+		} else {
+			invalid = append(invalid, index)
+		}
+	}
+	sort.Ints(indices)
+	// Corrupted participants are located once, on a random combination of their shares of all ciphertexts
+	rhos := randBatchScalars(len(cts))
+	combined := make([]*bls.PointG1, len(indices))
+	for i, index := range indices {
+		points := make([]*bls.PointG1, len(cts))
+		for j := range cts {
+			points[j] = inputs[index][j].pg1
+		}
+		combined[i] = multiExpG1(points, rhos)
+	}
+	errors, err := locateErrorsG1(indices, combined, threshold)
+	if err != nil {
+		return nil, err
+	}
+	for _, pos := range errors {
+		invalid = append(invalid, indices[pos])
+	}
+	sort.Ints(invalid)
+	return invalid, nil
+}
+
+// LocateInvalidSigShares tells participants with a corrupted signature share, without verification keys.
+// Shares are expected in the mode of the one with the lowest index.
+func LocateInvalidSigShares(inputs map[int]*SignatureShare, threshold int) ([]int, error) {
+	if len(inputs) == 0 {
+		return nil, NewDecodingNotEnoughShareError()
+	}
+	indices := make([]int, 0, len(inputs))
+	for index := range inputs {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	mode := inputs[indices[0]].Mode()
+	invalid := make([]int, 0)
+	valid := make([]int, 0, len(indices))
+	for _, index := range indices {
+		if inputs[index].Mode() == mode {
+			valid = append(valid, index)
+		} else {
+			invalid = append(invalid, index)
+		}
+	}
+	var errors []int
+	var err error
+	if mode == MinSigSize {
+		points := make([]*bls.PointG1, len(valid))
+		for i, index := range valid {
+			points[i] = inputs[index].pg1
+		}
+		errors, err = locateErrorsG1(valid, points, threshold)
+	} else {
+		points := make([]*bls.PointG2, len(valid))
+		for i, index := range valid {
+			points[i] = inputs[index].pg2
+		}
+		errors, err = locateErrorsG2(valid, points, threshold)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, pos := range errors {
+		invalid = append(invalid, valid[pos])
+	}
+	sort.Ints(invalid)
+	return invalid, nil
+}
+
+// decodeFr solves Q(xi)==yi*E(xi) for Q of degree below threshold+e and a monic E of degree e=(n-threshold)/2,
+// then f=Q/E, and corrupted positions are where f(xi)!=yi
+func decodeFr(xs []int, ys []*bls.Fr, threshold int) ([]*bls.Fr, []int, error) {
+	n := len(xs)
+	e := (n - threshold) / 2
+	// Unknowns are q0..q(threshold+e-1), then e0..e(e-1)
+	columns := threshold + 2*e
+	matrix := make([][]*bls.Fr, n)
+	for i := 0; i < n; i++ {
+		x := frFromInt(xs[i])
+		row := make([]*bls.Fr, columns+1)
+		power := bls.NewFr().One()
+		for j := 0; j < threshold+e; j++ {
+			row[j] = bls.NewFr().Set(power)
+			if j < e {
+				row[threshold+e+j] = bls.NewFr()
+				row[threshold+e+j].Mul(power, ys[i])
+				row[threshold+e+j].Neg(row[threshold+e+j])
+			}
+			if j == e {
+				row[columns] = bls.NewFr()
+				row[columns].Mul(power, ys[i])
+			}
+			power.Mul(power, x)
+		}
+		matrix[i] = row
+	}
+	solution, ok := solveFr(matrix, columns)
+	if !ok {
+		return nil, nil, NewDecodingFailedError()
+	}
+	locator := make([]*bls.Fr, e+1)
+	copy(locator, solution[threshold+e:])
+	locator[e] = bls.NewFr().One()
+	poly, remainder, _ := polynomial.New(solution[:threshold+e]).Div(polynomial.New(locator))
+	if !remainder.IsZero() {
+		return nil, nil, NewDecodingFailedError()
+	}
+	errors := make([]int, 0)
+	for i, y := range poly.EvaluateMany(frsFromInts(xs)) {
+		if !y.Equal(ys[i]) {
+			errors = append(errors, i)
+		}
+	}
+	if len(errors) > e {
+		return nil, nil, NewDecodingFailedError()
+	}
+	coeff := poly.Coefficients()
+	for len(coeff) < threshold {
+		coeff = append(coeff, bls.NewFr().Zero())
+	}
+	return coeff, errors, nil
+}
+
+// Gauss-Jordan elimination of an augmented matrix, free unknowns are zero
+func solveFr(matrix [][]*bls.Fr, columns int) ([]*bls.Fr, bool) {
+	pivots := make([]int, 0, columns)
+	row := 0
+	t := bls.NewFr()
+	for col := 0; col < columns && row < len(matrix); col++ {
+		pivot := -1
+		for i := row; i < len(matrix); i++ {
+			if !matrix[i][col].IsZero() {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		matrix[row], matrix[pivot] = matrix[pivot], matrix[row]
+		inv := bls.NewFr()
+		inv.Inverse(matrix[row][col])
+		for j := col; j <= columns; j++ {
+			matrix[row][j].Mul(matrix[row][j], inv)
+		}
+		for i := 0; i < len(matrix); i++ {
+			if i == row || matrix[i][col].IsZero() {
+				continue
+			}
+			factor := bls.NewFr().Set(matrix[i][col])
+			for j := col; j <= columns; j++ {
+				t.Mul(factor, matrix[row][j])
+				matrix[i][j].Sub(matrix[i][j], t)
+			}
+		}
+		pivots = append(pivots, col)
+		row++
+	}
+	// Rows left are 0==b
+	for i := row; i < len(matrix); i++ {
+		if !matrix[i][columns].IsZero() {
+			return nil, false
+		}
+	}
+	solution := make([]*bls.Fr, columns)
+	for j := range solution {
+		solution[j] = bls.NewFr()
+	}
+	for i, col := range pivots {
+		solution[col].Set(matrix[i][columns])
+	}
+	return solution, true
+}
+
+// locateErrors finds the smallest set of positions, of at most (n-threshold)/2, whose removal leaves values on a polynomial of degree below threshold.
+// Values are group elements, so Berlekamp-Welch does not apply, and no polynomial time decoder in the exponent is known.
+// Sets are tried by increasing size, each with one multi-scalar multiplication, which costs sum(C(n,e)) for e corrupted shares,
+// so the search stops after maxDecodingAttempts sets. zero tells if sum(scalars[k]*Y[positions[k]]) is zero.
+func locateErrors(xs []int, threshold int, zero func(positions []int, scalars []*bls.Fr) bool) ([]int, error) {
+	n := len(xs)
+	if n < threshold {
+		return nil, NewDecodingNotEnoughShareError()
+	}
+	// Values on the rest R lie on a polynomial of degree below threshold, iff sum(ui*W(xi)*Yi)==0 over R
+	// for W of degree below |R|-threshold, where ui=1/prod(xi-xj). W is random, so one check covers all.
+	denominators := make([]*bls.Fr, n)
+	for i := 0; i < n; i++ {
+		denominators[i] = bls.NewFr().One()
+		for j := 0; j < n; j++ {
+			if i != j {
+				denominators[i].Mul(denominators[i], frFromInt(xs[i]-xs[j]))
+			}
+		}
+		denominators[i].Inverse(denominators[i])
+	}
+	weights := randBatchScalars(n - threshold)
+	t := bls.NewFr()
+	attempts := 0
+	for e := 0; e <= (n-threshold)/2; e++ {
+		excluded := make([]int, e)
+		for i := range excluded {
+			excluded[i] = i
+		}
+		for {
+			if attempts == maxDecodingAttempts {
+				return nil, NewDecodingLimitError()
+			}
+			attempts++
+			positions := make([]int, 0, n-e)
+			scalars := make([]*bls.Fr, 0, n-e)
+			k := 0
+			for i := 0; i < n; i++ {
+				if k < e && excluded[k] == i {
+					k++
+					continue
+				}
+				// ui over R is the one over all positions times prod(xi-xj) of excluded j
+				u := bls.NewFr().Set(denominators[i])
+				for _, j := range excluded {
+					u.Mul(u, frFromInt(xs[i]-xs[j]))
+				}
+				w := bls.NewFr()
+				x := frFromInt(xs[i])
+				for d := n - e - threshold - 1; d >= 0; d-- {
+					w.Mul(w, x)
+					w.Add(w, weights[d])
+				}
+				t.Mul(u, w)
+				positions = append(positions, i)
+				scalars = append(scalars, bls.NewFr().Set(t))
+			}
+			if zero(positions, scalars) {
+				return excluded, nil
+			}
+			if !nextComb(excluded, n) {
+				break
+			}
+		}
+	}
+	return nil, NewDecodingFailedError()
+}
+
+// searchShares tries sets of threshold positions, and returns the first one that verifies.
+// All sets are tried if there are at most maxDecodingAttempts of them, otherwise as many random ones,
+// so that corrupted shares at any fixed positions cannot block the search.
+func searchShares(n int, threshold int, verify func(positions []int) bool) ([]int, error) {
+	if threshold < 1 || n < threshold {
+		return nil, NewDecodingNotEnoughShareError()
+	}
+	if new(big.Int).Binomial(int64(n), int64(threshold)).Cmp(big.NewInt(int64(maxDecodingAttempts))) <= 0 {
+		comb := make([]int, threshold)
+		for i := range comb {
+			comb[i] = i
+		}
+		for {
+			if verify(comb) {
+				return comb, nil
+			}
+			if !nextComb(comb, n) {
+				return nil, NewDecodingFailedError()
+			}
+		}
+	}
+	for attempts := 0; attempts < maxDecodingAttempts; attempts++ {
+		if comb := randComb(n, threshold); verify(comb) {
+			return comb, nil
+		}
+	}
+	return nil, NewDecodingLimitError()
+}
+
+// Random combination in increasing order, by a partial Fisher-Yates shuffle
+func randComb(n int, k int) []int {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := 0; i < k; i++ {
+		j, _ := rand.Int(rand.Reader, big.NewInt(int64(n-i)))
+		pick := i + int(j.Int64())
+		perm[i], perm[pick] = perm[pick], perm[i]
+	}
+	comb := perm[:k]
+	sort.Ints(comb)
+	return comb
+}
+
+// Next combination in lexicographic order
+func nextComb(comb []int, n int) bool {
+	k := len(comb)
+	i := k - 1
+	for i >= 0 && comb[i] == n-k+i {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+	comb[i]++
+	for j := i + 1; j < k; j++ {
+		comb[j] = comb[j-1] + 1
+	}
+	return true
+}
+
+func locateErrorsG1(xs []int, ys []*bls.PointG1, threshold int) ([]int, error) {
+	g1 := bls.NewG1()
+	return locateErrors(xs, threshold, func(positions []int, scalars []*bls.Fr) bool {
+		points := make([]*bls.PointG1, len(positions))
+		for i, pos := range positions {
+			points[i] = ys[pos]
+		}
+		return g1.IsZero(multiExpG1(points, scalars))
+	})
+}
+
+func locateErrorsG2(xs []int, ys []*bls.PointG2, threshold int) ([]int, error) {
+	g2 := bls.NewG2()
+	return locateErrors(xs, threshold, func(positions []int, scalars []*bls.Fr) bool {
+		points := make([]*bls.PointG2, len(positions))
+		for i, pos := range positions {
+			points[i] = ys[pos]
+		}
+		return g2.IsZero(multiExpG2(points, scalars))
+	})
+}
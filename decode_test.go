@@ -0,0 +1,280 @@
+package tpke
+
+import (
+	"reflect"
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestRecoverSecret(t *testing.T) {
+	size := 10
+	threshold := 4
+	secret := RandomSecret(threshold)
+	shares := make(map[int]*bls.Fr)
+	for i := 1; i <= size; i++ {
+		shares[i] = secret.Evaluate(*frFromInt(i))
+	}
+	// Up to (size-threshold)/2 corrupted shares
+	shares[2] = RandScalar()
+	shares[5] = RandScalar()
+	shares[9] = RandScalar()
+	s, invalid, err := RecoverSecret(shares, threshold)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !s.Equal(secret.poly.coeff[0]) {
+		t.Fatalf("unexpected secret")
+	}
+	if !reflect.DeepEqual(invalid, []int{2, 5, 9}) {
+		t.Fatalf("unexpected invalid shares %v", invalid)
+	}
+
+	// One more is beyond the bound
+	shares[10] = RandScalar()
+	if s, _, err := RecoverSecret(shares, threshold); err == nil && s.Equal(secret.poly.coeff[0]) {
+		t.Fatalf("decoding beyond the bound")
+	}
+}
+
+func TestLocateErrors(t *testing.T) {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	size := 9
+	threshold := 3
+	secret := RandomSecret(threshold)
+	xs := make([]int, size)
+	ys1 := make([]*bls.PointG1, size)
+	ys2 := make([]*bls.PointG2, size)
+	for i := 0; i < size; i++ {
+		xs[i] = 2*i + 1
+		y := secret.Evaluate(*frFromInt(xs[i]))
+		ys1[i] = g1.MulScalar(g1.New(), &bls.G1One, y)
+		ys2[i] = g2.MulScalar(g2.New(), &bls.G2One, y)
+	}
+	errors, err := locateErrorsG1(xs, ys1, threshold)
+	if err != nil || len(errors) != 0 {
+		t.Fatalf("unexpected errors %v", errors)
+	}
+	for _, pos := range []int{0, 4, 8} {
+		ys1[pos] = RandPG1()
+		ys2[pos] = g2.Add(g2.New(), ys2[pos], &bls.G2One)
+	}
+	errors, err = locateErrorsG1(xs, ys1, threshold)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !reflect.DeepEqual(errors, []int{0, 4, 8}) {
+		t.Fatalf("unexpected errors %v", errors)
+	}
+	errors, err = locateErrorsG2(xs, ys2, threshold)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !reflect.DeepEqual(errors, []int{0, 4, 8}) {
+		t.Fatalf("unexpected errors %v", errors)
+	}
+}
+
+func TestDecodeCorruptedShares(t *testing.T) {
+	size := 10
+	threshold := 6
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+
+	// Corrupt one share of participant 3 and all shares of participant 8
+	msgs := []*bls.PointG1{RandPG1(), RandPG1(), RandPG1()}
+	cipherTexts := Encrypt(msgs, pubkey)
+	shares := decryptShare(cipherTexts, prvkeys)
+	shares[3][1].pg1 = RandPG1()
+	for _, share := range shares[8] {
+		share.pg1 = RandPG1()
+	}
+	invalid, err := LocateInvalidDecryptionShares(cipherTexts, shares, threshold)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !reflect.DeepEqual(invalid, []int{3, 8}) {
+		t.Fatalf("unexpected invalid shares %v", invalid)
+	}
+	results, err := Decrypt(cipherTexts, shares, pubkey, threshold, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i := range msgs {
+		if !bls.NewG1().Equal(msgs[i], results[i]) {
+			t.Fatalf("decryption failed.")
+		}
+	}
+
+	// Signature shares
+	msg := []byte("pizza")
+	sigShares := make(map[int]*SignatureShare)
+	for i := 1; i <= size; i++ {
+		sigShares[i] = prvkeys[i].SignShare(msg)
+	}
+	sigShares[1] = prvkeys[2].SignShare(msg)
+	sigShares[10] = prvkeys[10].SignShare([]byte("pasta"))
+	invalid, err = LocateInvalidSigShares(sigShares, threshold)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !reflect.DeepEqual(invalid, []int{1, 10}) {
+		t.Fatalf("unexpected invalid shares %v", invalid)
+	}
+	sig, err := AggregateAndVerifySig(pubkey, msg, threshold, sigShares, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !pubkey.VerifySig(msg, sig) {
+		t.Fatalf("invalid signature")
+	}
+
+	// Beyond the decoding bound, any threshold honest shares still aggregate and decrypt
+	sigShares[5] = prvkeys[5].SignShare([]byte("pasta"))
+	sig, err = AggregateAndVerifySig(pubkey, msg, threshold, sigShares, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !pubkey.VerifySig(msg, sig) {
+		t.Fatalf("invalid signature")
+	}
+	shares[5][0].pg1 = RandPG1()
+	results, err = Decrypt(cipherTexts, shares, pubkey, threshold, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i := range msgs {
+		if !bls.NewG1().Equal(msgs[i], results[i]) {
+			t.Fatalf("decryption failed.")
+		}
+	}
+
+	// Not enough honest shares
+	sigShares[7] = prvkeys[7].SignShare([]byte("pasta"))
+	sigShares[9] = prvkeys[9].SignShare([]byte("pasta"))
+	if _, err := AggregateAndVerifySig(pubkey, msg, threshold, sigShares, dkg.GetScaler()); err == nil {
+		t.Fatalf("aggregation without enough honest shares")
+	}
+	shares[7][2].pg1 = RandPG1()
+	shares[9][0].pg1 = RandPG1()
+	if _, err := Decrypt(cipherTexts, shares, pubkey, threshold, dkg.GetScaler()); err == nil {
+		t.Fatalf("decryption without enough honest shares")
+	}
+}
+
+func TestDecryptWithKeys(t *testing.T) {
+	size := 10
+	threshold := 4
+	for _, mode := range []SignatureMode{MinPubKeySize, MinSigSize} {
+		dkg := NewDKGWithMode(size, threshold, mode)
+		dkg.Prepare()
+		if err := dkg.VerifyPrepare(); err != nil {
+			t.Fatalf(err.Error())
+		}
+		pubkey := dkg.PublishGlobalPublicKey()
+		prvkeys := dkg.GetPrivateKeysFromPrepare()
+		vks := dkg.PublishVerificationKeys()
+
+		// Six corrupted participants, far beyond the decoding bound
+		msgs := []*bls.PointG1{RandPG1(), RandPG1()}
+		cipherTexts := Encrypt(msgs, pubkey)
+		if mode == MinSigSize {
+			cipherTexts = append(cipherTexts, EncryptWithFormat(msgs[:1], pubkey, CompactFormat)...)
+			msgs = append(msgs, msgs[0])
+		}
+		shares := decryptShare(cipherTexts, prvkeys)
+		for _, index := range []int{1, 2, 4, 6, 8} {
+			shares[index][1].pg1 = RandPG1()
+		}
+		shares[9] = shares[9][:1]
+		results, proofs, invalid, err := DecryptWithKeys(cipherTexts, shares, pubkey, vks, threshold, dkg.GetScaler())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !reflect.DeepEqual(invalid, []int{1, 2, 4, 6, 8, 9}) {
+			t.Fatalf("unexpected invalid shares %v", invalid)
+		}
+		for i := range msgs {
+			if err := pubkey.VerifyDecryption(cipherTexts[i], results[i], proofs[i]); err != nil || !bls.NewG1().Equal(msgs[i], results[i]) {
+				t.Fatalf("decryption failed.")
+			}
+		}
+
+		shares[3][0].pg1 = RandPG1()
+		_, _, invalid, err = DecryptWithKeys(cipherTexts, shares, pubkey, vks, threshold, dkg.GetScaler())
+		shareErr, ok := err.(*IndexedError)
+		if !ok || !reflect.DeepEqual(shareErr.Indices, invalid) || len(invalid) != 7 {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+}
+
+func TestDecodingLimit(t *testing.T) {
+	limit := maxDecodingAttempts
+	maxDecodingAttempts = 16
+	defer func() {
+		maxDecodingAttempts = limit
+	}()
+
+	// Only one of C(20,10) sets is honest
+	size := 20
+	threshold := 10
+	pubkey, prvkeys, vks := dealKeys(size, threshold)
+	cipherTexts := Encrypt([]*bls.PointG1{RandPG1()}, pubkey)
+	shares := decryptShare(cipherTexts, prvkeys)
+	for index := 1; index <= size; index += 2 {
+		shares[index][0].pg1 = RandPG1()
+	}
+	if _, err := LocateInvalidDecryptionShares(cipherTexts, shares, threshold); err == nil {
+		t.Fatalf("search beyond the limit")
+	}
+	// Honest shares are beyond the limited search, but not beyond verification keys
+	if _, err := Decrypt(cipherTexts, shares, pubkey, threshold, 1); err == nil {
+		t.Fatalf("search beyond the limit")
+	}
+	if _, _, _, err := DecryptWithKeys(cipherTexts, shares, pubkey, vks, threshold, 1); err != nil {
+		t.Fatalf(err.Error())
+	}
+}
+
+func TestDecodingLowIndex(t *testing.T) {
+	size := 16
+	threshold := 8
+	pubkey, prvkeys, _ := dealKeys(size, threshold)
+	msgs := []*bls.PointG1{RandPG1()}
+	cipherTexts := Encrypt(msgs, pubkey)
+	shares := decryptShare(cipherTexts, prvkeys)
+
+	// Locating the four takes more than the search limit, and every early set of threshold shares holds the first one
+	for _, index := range []int{1, 14, 15, 16} {
+		shares[index][0].pg1 = RandPG1()
+	}
+	if _, err := LocateInvalidDecryptionShares(cipherTexts, shares, threshold); err == nil {
+		t.Fatalf("search beyond the limit")
+	}
+	results, err := Decrypt(cipherTexts, shares, pubkey, threshold, 1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bls.NewG1().Equal(msgs[0], results[0]) {
+		t.Fatalf("decryption failed.")
+	}
+}
+
+// Keys of a single dealer with scaler 1, since the scaler of a DKG takes long to compute for many participants
+func dealKeys(size int, threshold int) (*PublicKey, map[int]*PrivateKey, map[int]*PublicKey) {
+	secret := RandomSecret(threshold)
+	prvkeys := make(map[int]*PrivateKey)
+	vks := make(map[int]*PublicKey)
+	for i := 1; i <= size; i++ {
+		prvkeys[i] = NewPrivateKey([]*bls.Fr{secret.Evaluate(*frFromInt(i))})
+		vks[i] = prvkeys[i].GetPublicKey()
+	}
+	return NewGlobalPublicKey([]*Commitment{secret.Commitment()}, 1), prvkeys, vks
+}
@@ -12,7 +12,10 @@ import (
 type DKG struct {
 	size         int
 	threshold    int
-	scaler       int // Scaler for global public key, to speed up decryption
+	scaler       int    // Scaler for global public key, to speed up decryption
+	epoch        uint64 // Key epoch, increased every time a new global key is prepared
+	mode         SignatureMode
+	setup        *KZGSetup // Participants deal with KZG commitments if set, otherwise with PVSS
 	participants []*Participant
 	messageBox   [][][]byte
 }
@@ -23,11 +26,19 @@ type Participant struct {
 	secret          *Secret
 	lastPVSS        *PVSS
 	pvss            *PVSS
+	lastDealing     *KZGDealing
+	dealing         *KZGDealing
+	kzgShares       []*KZGShare // Received in the latest dealing round
 	resharedSecrets []*bls.Fr
 	receivedSecrets []*bls.Fr
 }
 
 func NewDKG(size int, threshold int) *DKG {
+	return NewDKGWithMode(size, threshold, MinPubKeySize)
+}
+
+// In min-sig mode, participants also publish shares in G2 to output verification keys for G1 signatures
+func NewDKGWithMode(size int, threshold int, mode SignatureMode) *DKG {
 	participants := make([]*Participant, size)
 	source := rand.NewSource(time.Now().UnixNano())
 	random := rand.New(source)
@@ -39,13 +50,26 @@ func NewDKG(size int, threshold int) *DKG {
 		size:         size,
 		threshold:    threshold,
 		scaler:       getEncryptionScaler(size, threshold),
+		mode:         mode,
 		participants: participants,
 	}
 }
 
+// In KZG mode, a dealing is a constant size commitment, and shares come with openings instead of public shares of every participant.
+// The setup must support polynomials of degree threshold-1, and hold tau^(D-t+1)*G2 to bound dealings to that degree.
+func NewDKGWithKZG(size int, threshold int, mode SignatureMode, setup *KZGSetup) (*DKG, error) {
+	if _, err := setup.boundShift(threshold); err != nil {
+		return nil, err
+	}
+	dkg := NewDKGWithMode(size, threshold, mode)
+	dkg.setup = setup
+	return dkg, nil
+}
+
 func (dkg *DKG) Prepare() {
 	source := rand.NewSource(time.Now().UnixNano())
 	random := rand.New(source)
+	dkg.epoch++
 	dkg.messageBox = make([][][]byte, dkg.size)
 	for i := 0; i < dkg.size; i++ {
 		dkg.messageBox[i] = make([][]byte, dkg.size)
@@ -53,14 +77,7 @@ func (dkg *DKG) Prepare() {
 	for i := 0; i < dkg.size; i++ {
 		// Init random polynomial a
 		dkg.participants[i].GenerateSecret(dkg.threshold)
-		// Compute PVSS
-		sharedSecrets := dkg.participants[i].GenerateShares(dkg.size)
-		// Send messages
-		for j := 0; j < dkg.size; j++ {
-			sharedSecret := sharedSecrets[j].ToBytes()
-			msg, _ := ecies.Encrypt(random, dkg.participants[j].ethPubKey, sharedSecret[:32], nil, nil)
-			dkg.messageBox[j][i] = msg
-		}
+		dkg.deal(i, random)
 	}
 }
 
@@ -75,18 +92,36 @@ func (dkg *DKG) Reshare() {
 	for i := 0; i < dkg.size; i++ {
 		// Bias local secret with delta
 		dkg.participants[i].RenovateSecret()
-		// Compute PVSS
-		sharedSecrets := dkg.participants[i].GenerateShares(dkg.size)
-		// Send messages
+		dkg.deal(i, random)
+	}
+}
+
+// Compute PVSS or KZG dealing, and send messages
+func (dkg *DKG) deal(i int, random *rand.Rand) {
+	if dkg.setup != nil {
+		// Degree is checked against the setup on creation
+		shares, _ := dkg.participants[i].GenerateKZGShares(dkg.size, dkg.setup)
 		for j := 0; j < dkg.size; j++ {
-			sharedSecret := sharedSecrets[j].ToBytes()
-			msg, _ := ecies.Encrypt(random, dkg.participants[j].ethPubKey, sharedSecret[:32], nil, nil)
+			msg, _ := ecies.Encrypt(random, dkg.participants[j].ethPubKey, shares[j].ToBytes(), nil, nil)
 			dkg.messageBox[j][i] = msg
 		}
+		return
+	}
+	sharedSecrets := dkg.participants[i].GenerateShares(dkg.size)
+	if dkg.mode == MinSigSize {
+		dkg.participants[i].pvss.CommitSharesG2(sharedSecrets)
+	}
+	for j := 0; j < dkg.size; j++ {
+		sharedSecret := sharedSecrets[j].ToBytes()
+		msg, _ := ecies.Encrypt(random, dkg.participants[j].ethPubKey, sharedSecret[:32], nil, nil)
+		dkg.messageBox[j][i] = msg
 	}
 }
 
 func (dkg *DKG) VerifyPrepare() error {
+	if dkg.setup != nil {
+		return dkg.verifyKZG(false)
+	}
 	for i := 0; i < dkg.size; i++ {
 		// Verify PVSS
 		if !dkg.participants[i].VerifyPreparePVSS() {
@@ -94,7 +129,6 @@ func (dkg *DKG) VerifyPrepare() error {
 		}
 	}
 	g1 := bls.NewG1()
-	pairing := bls.NewEngine()
 	for i := 0; i < dkg.size; i++ {
 		dkg.participants[i].receivedSecrets = make([]*bls.Fr, dkg.size)
 		// Verify received secrets
@@ -103,10 +137,8 @@ func (dkg *DKG) VerifyPrepare() error {
 			// e(r1*fi,g2)=e(bigfi,r2)
 			fi := bls.NewFr().FromBytes(ss)
 			commitment := dkg.participants[j].pvss
-			r1 := g1.New().Set(commitment.r1)
-			e1 := pairing.AddPair(g1.MulScalar(r1, r1, fi), &bls.G2One).Result()
-			e2 := pairing.AddPair(commitment.bigf[i], commitment.r2).Result()
-			if !e1.Equal(e2) {
+			r1 := g1.MulScalar(g1.New(), commitment.r1, fi)
+			if !pairingCheck(r1, &bls.G2One, commitment.bigf[i], commitment.r2) {
 				return NewDKGSecretError()
 			}
 			// Cache received secrets
@@ -118,6 +150,9 @@ func (dkg *DKG) VerifyPrepare() error {
 }
 
 func (dkg *DKG) VerifyReshare() error {
+	if dkg.setup != nil {
+		return dkg.verifyKZG(true)
+	}
 	for i := 0; i < dkg.size; i++ {
 		// Verify PVSS
 		if !dkg.participants[i].VerifyResharePVSS() {
@@ -125,7 +160,6 @@ func (dkg *DKG) VerifyReshare() error {
 		}
 	}
 	g1 := bls.NewG1()
-	pairing := bls.NewEngine()
 	for i := 0; i < dkg.size; i++ {
 		dkg.participants[i].resharedSecrets = make([]*bls.Fr, dkg.size)
 		// Verify received secrets
@@ -134,10 +168,8 @@ func (dkg *DKG) VerifyReshare() error {
 			// e(r1*fi,g2)=e(bigfi,r2)
 			fi := bls.NewFr().FromBytes(ss)
 			commitment := dkg.participants[j].pvss
-			r1 := g1.New().Set(commitment.r1)
-			e1 := pairing.AddPair(g1.MulScalar(r1, r1, fi), &bls.G2One).Result()
-			e2 := pairing.AddPair(commitment.bigf[i], commitment.r2).Result()
-			if !e1.Equal(e2) {
+			r1 := g1.MulScalar(g1.New(), commitment.r1, fi)
+			if !pairingCheck(r1, &bls.G2One, commitment.bigf[i], commitment.r2) {
 				return NewDKGSecretError()
 			}
 			// Cache received secrets
@@ -148,13 +180,141 @@ func (dkg *DKG) VerifyReshare() error {
 	return nil
 }
 
+// Every participant checks all dealings, and its shares from all dealers with one pairing check
+func (dkg *DKG) verifyKZG(reshare bool) error {
+	dealings := dkg.PublishDealings()
+	for i := 0; i < dkg.size; i++ {
+		p := dkg.participants[i]
+		if !p.dealing.Verify(dkg.setup, dkg.threshold) || (reshare && !p.dealing.VerifyRenovate(p.lastDealing)) {
+			return NewDKGPVSSError()
+		}
+	}
+	for i := 0; i < dkg.size; i++ {
+		shares := make([]*KZGShare, dkg.size)
+		secrets := make([]*bls.Fr, dkg.size)
+		for j := 0; j < dkg.size; j++ {
+			ss, _ := dkg.participants[i].ethPrvKey.Decrypt(dkg.messageBox[i][j], nil, nil)
+			share, err := BytesToKZGShare(ss)
+			if err != nil {
+				return NewDKGSecretError()
+			}
+			shares[j] = share
+			secrets[j] = share.value
+		}
+		if !VerifyKZGShares(dkg.setup, dealings, i+1, shares) {
+			return NewDKGSecretError()
+		}
+		// Cache received secrets
+		dkg.participants[i].kzgShares = shares
+		if reshare {
+			dkg.participants[i].resharedSecrets = secrets
+		} else {
+			dkg.participants[i].receivedSecrets = secrets
+		}
+	}
+	return nil
+}
+
 func (dkg *DKG) PublishGlobalPublicKey() *PublicKey {
+	if dkg.setup != nil {
+		// Compute public key S=sum(A0) and S'=sum(A0') from dealings
+		g1 := bls.NewG1()
+		g2 := bls.NewG2()
+		pg1 := g1.Zero()
+		pg2 := g2.Zero()
+		for i := 0; i < dkg.size; i++ {
+			g1.Add(pg1, pg1, dkg.participants[i].dealing.pub)
+			g2.Add(pg2, pg2, dkg.participants[i].dealing.pub2)
+		}
+		return &PublicKey{
+			pg1: g1.MulScalar(pg1, pg1, frFromInt(dkg.scaler)),
+			pg2: g2.MulScalar(pg2, pg2, frFromInt(dkg.scaler)),
+		}
+	}
 	// Compute public key S=sum(A0)
 	scs := make([]*Commitment, dkg.size)
 	for i := 0; i < dkg.size; i++ {
 		scs[i] = dkg.participants[i].pvss.commitment
 	}
-	return NewGlobalPublicKey(scs, dkg.scaler)
+	pk := NewGlobalPublicKey(scs, dkg.scaler)
+	// Compute the G2 form S'=sum(A0')
+	g2 := bls.NewG2()
+	pg2 := g2.New().Set(dkg.participants[0].pvss.pub2)
+	for i := 1; i < dkg.size; i++ {
+		g2.Add(pg2, pg2, dkg.participants[i].pvss.pub2)
+	}
+	pk.pg2 = g2.MulScalar(pg2, pg2, frFromInt(dkg.scaler))
+	return pk
+}
+
+// Verification key of participant i is sum(Fj(i)), which equals to its private key times G1.
+// In min-sig mode, the key also has its G2 form sum(Fj'(i)).
+func (dkg *DKG) PublishVerificationKeys() map[int]*PublicKey {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	vks := make(map[int]*PublicKey)
+	if dkg.setup != nil {
+		// Participants publish sk*G1, which VerifyKZGVerificationKey checks with PublishVerificationKeyProofs
+		for i := 0; i < dkg.size; i++ {
+			sk := bls.NewFr().Zero()
+			for _, share := range dkg.participants[i].kzgShares {
+				sk.Add(sk, share.value)
+			}
+			vks[i+1] = &PublicKey{
+				pg1: g1FixedBase().mul(sk),
+			}
+			if dkg.mode == MinSigSize {
+				vks[i+1].pg2 = g2FixedBase().mul(sk)
+			}
+		}
+		return vks
+	}
+	for i := 0; i < dkg.size; i++ {
+		pg1 := g1.Zero()
+		for j := 0; j < dkg.size; j++ {
+			g1.Add(pg1, pg1, dkg.participants[j].pvss.bigf[i])
+		}
+		vks[i+1] = &PublicKey{
+			pg1: pg1,
+		}
+		if dkg.mode == MinSigSize {
+			pg2 := g2.Zero()
+			for j := 0; j < dkg.size; j++ {
+				g2.Add(pg2, pg2, dkg.participants[j].pvss.bigf2[i])
+			}
+			vks[i+1].pg2 = pg2
+		}
+	}
+	return vks
+}
+
+// PublishVerificationKeyProofs gives the sum of openings received by each participant in KZG mode, and nil otherwise
+func (dkg *DKG) PublishVerificationKeyProofs() map[int]*bls.PointG1 {
+	if dkg.setup == nil {
+		return nil
+	}
+	g1 := bls.NewG1()
+	proofs := make(map[int]*bls.PointG1)
+	for i := 0; i < dkg.size; i++ {
+		proof := g1.Zero()
+		for _, share := range dkg.participants[i].kzgShares {
+			g1.Add(proof, proof, share.proof)
+		}
+		proofs[i+1] = proof
+	}
+	return proofs
+}
+
+// PublishDealings gives the latest KZG dealings of all participants, and nil if the DKG is not in KZG mode
+func (dkg *DKG) PublishDealings() []*KZGDealing {
+	if dkg.setup == nil {
+		return nil
+	}
+	dealings := make([]*KZGDealing, dkg.size)
+	for i := 0; i < dkg.size; i++ {
+		dealings[i] = dkg.participants[i].dealing
+	}
+	return dealings
 }
 
 func (dkg *DKG) GetPrivateKeysFromPrepare() map[int]*PrivateKey {
@@ -177,6 +337,14 @@ func (dkg *DKG) GetScaler() int {
 	return dkg.scaler
 }
 
+func (dkg *DKG) GetEpoch() uint64 {
+	return dkg.epoch
+}
+
+func (dkg *DKG) GetMode() SignatureMode {
+	return dkg.mode
+}
+
 func NewParticipant(key *ecies.PrivateKey) *Participant {
 	return &Participant{
 		ethPrvKey: key,
@@ -204,6 +372,16 @@ func (p *Participant) GenerateShares(size int) []*bls.Fr {
 	return ss
 }
 
+func (p *Participant) GenerateKZGShares(size int, setup *KZGSetup) ([]*KZGShare, error) {
+	dealing, shares, err := GenerateKZGDealing(setup, size, p.secret)
+	if err != nil {
+		return nil, err
+	}
+	p.lastDealing = p.dealing
+	p.dealing = dealing
+	return shares, nil
+}
+
 func (p *Participant) VerifyPreparePVSS() bool {
 	return p.pvss.VerifyCommitment()
 }
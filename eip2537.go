@@ -0,0 +1,167 @@
+package tpke
+
+import (
+	bls "github.com/kilic/bls12-381"
+)
+
+// EIP-2537 pads every base field element to 64 bytes, and orders a G2 coordinate as c0||c1
+var eip2537FpSize = 64
+
+func eip2537EncodeFp(out []byte, fp []byte) {
+	copy(out[eip2537FpSize-fpByteSize:eip2537FpSize], fp)
+}
+
+func eip2537DecodeFp(in []byte) ([]byte, error) {
+	for i := 0; i < eip2537FpSize-fpByteSize; i++ {
+		if in[i] != 0 {
+			return nil, NewEIP2537PointError()
+		}
+	}
+	return in[eip2537FpSize-fpByteSize : eip2537FpSize], nil
+}
+
+// Point at infinity is encoded as zeros
+func eip2537EncodeG1(p *bls.PointG1) []byte {
+	b := bls.NewG1().ToBytes(p)
+	out := make([]byte, 2*eip2537FpSize)
+	eip2537EncodeFp(out[:eip2537FpSize], b[:fpByteSize])
+	eip2537EncodeFp(out[eip2537FpSize:], b[fpByteSize:])
+	return out
+}
+
+func eip2537EncodeG2(p *bls.PointG2) []byte {
+	// Uncompressed G2 of kilic is x.c1||x.c0||y.c1||y.c0
+	b := bls.NewG2().ToBytes(p)
+	out := make([]byte, 4*eip2537FpSize)
+	eip2537EncodeFp(out[:eip2537FpSize], b[fpByteSize:2*fpByteSize])
+	eip2537EncodeFp(out[eip2537FpSize:2*eip2537FpSize], b[:fpByteSize])
+	eip2537EncodeFp(out[2*eip2537FpSize:3*eip2537FpSize], b[3*fpByteSize:])
+	eip2537EncodeFp(out[3*eip2537FpSize:], b[2*fpByteSize:3*fpByteSize])
+	return out
+}
+
+// Decoded points are checked to be in the subgroup
+func eip2537DecodeG1(in []byte) (*bls.PointG1, error) {
+	if len(in) != 2*eip2537FpSize {
+		return nil, NewEIP2537PointError()
+	}
+	b := make([]byte, 2*fpByteSize)
+	for i := 0; i < 2; i++ {
+		fp, err := eip2537DecodeFp(in[i*eip2537FpSize:])
+		if err != nil {
+			return nil, err
+		}
+		copy(b[i*fpByteSize:], fp)
+	}
+	g1 := bls.NewG1()
+	p, err := g1.FromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if !g1.InCorrectSubgroup(p) {
+		return nil, NewEIP2537PointError()
+	}
+	return p, nil
+}
+
+func eip2537DecodeG2(in []byte) (*bls.PointG2, error) {
+	if len(in) != 4*eip2537FpSize {
+		return nil, NewEIP2537PointError()
+	}
+	// Swap c0 and c1 back
+	order := []int{1, 0, 3, 2}
+	b := make([]byte, 4*fpByteSize)
+	for i := 0; i < 4; i++ {
+		fp, err := eip2537DecodeFp(in[i*eip2537FpSize:])
+		if err != nil {
+			return nil, err
+		}
+		copy(b[order[i]*fpByteSize:], fp)
+	}
+	g2 := bls.NewG2()
+	p, err := g2.FromBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if !g2.InCorrectSubgroup(p) {
+		return nil, NewEIP2537PointError()
+	}
+	return p, nil
+}
+
+// ToEIP2537 encodes the key in G1, which is 128 bytes
+func (pk *PublicKey) ToEIP2537() []byte {
+	return eip2537EncodeG1(pk.pg1)
+}
+
+// ToEIP2537G2 encodes the G2 form of the key, which is 256 bytes and verifies min-sig signatures
+func (pk *PublicKey) ToEIP2537G2() ([]byte, error) {
+	if pk.pg2 == nil {
+		return nil, NewEIP2537KeyError()
+	}
+	return eip2537EncodeG2(pk.pg2), nil
+}
+
+func EIP2537ToPublicKey(b []byte) (*PublicKey, error) {
+	pg1, err := eip2537DecodeG1(b)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{
+		pg1: pg1,
+	}, nil
+}
+
+// ToEIP2537 encodes a min-sig signature in 128 bytes, and others in 256 bytes
+func (s *Signature) ToEIP2537() []byte {
+	if s.Mode() == MinSigSize {
+		return eip2537EncodeG1(s.pg1)
+	}
+	return eip2537EncodeG2(s.pg2)
+}
+
+func EIP2537ToSig(b []byte) (*Signature, error) {
+	if len(b) == 2*eip2537FpSize {
+		pg1, err := eip2537DecodeG1(b)
+		if err != nil {
+			return nil, err
+		}
+		return &Signature{
+			pg1: pg1,
+		}, nil
+	}
+	pg2, err := eip2537DecodeG2(b)
+	if err != nil {
+		return nil, err
+	}
+	return NewSignature(pg2), nil
+}
+
+// ToEIP2537 encodes a standard ciphertext as C||R1||R2 in 512 bytes,
+// and a compact ciphertext as C||R1||c||z in 320 bytes, with 32-byte scalars.
+// Epoch is not a part of the encoding.
+func (ct *CipherText) ToEIP2537() []byte {
+	if ct.Format() == CompactFormat {
+		out := make([]byte, 4*eip2537FpSize+2*frByteSize)
+		copy(out[:2*eip2537FpSize], eip2537EncodeG1(ct.cMsg))
+		copy(out[2*eip2537FpSize:4*eip2537FpSize], eip2537EncodeG1(ct.bigR))
+		copy(out[4*eip2537FpSize:4*eip2537FpSize+frByteSize], ct.proof.c.ToBytes())
+		copy(out[4*eip2537FpSize+frByteSize:], ct.proof.z.ToBytes())
+		return out
+	}
+	out := make([]byte, 8*eip2537FpSize)
+	copy(out[:2*eip2537FpSize], eip2537EncodeG1(ct.cMsg))
+	copy(out[2*eip2537FpSize:4*eip2537FpSize], eip2537EncodeG1(ct.bigR))
+	copy(out[4*eip2537FpSize:], eip2537EncodeG2(ct.commitment))
+	return out
+}
+
+// ToEIP2537 encodes rpk in 128 bytes
+func (p *DecryptionProof) ToEIP2537() []byte {
+	return eip2537EncodeG1(p.rpk)
+}
+
+// EIP2537EncodeMessage encodes a decrypted message point in 128 bytes
+func EIP2537EncodeMessage(msg *bls.PointG1) []byte {
+	return eip2537EncodeG1(msg)
+}
@@ -0,0 +1,325 @@
+package tpke
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	bls "github.com/kilic/bls12-381"
+)
+
+// Precompiles of EIP-2537 are active from Prague, only read the table here
+func runPrecompile(t *testing.T, addr byte, input []byte) []byte {
+	p, ok := vm.PrecompiledContractsPrague[common.BytesToAddress([]byte{addr})]
+	if !ok {
+		t.Fatalf("precompile not found")
+	}
+	out, err := p.Run(input)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return out
+}
+
+// Same steps as the hash to curve of the generated contract
+func precompileHashToCurve(t *testing.T, msg []byte, dst []byte, mode SignatureMode) []byte {
+	length := 256
+	if mode == MinSigSize {
+		length = 128
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+	h := sha256.New()
+	h.Write(make([]byte, 64))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+	bi := sha256.Sum256(append(append(append([]byte{}, b0...), 1), dstPrime...))
+	uniform := append([]byte{}, bi[:]...)
+	for i := 2; i <= length/32; i++ {
+		tmp := make([]byte, 32)
+		for j := 0; j < 32; j++ {
+			tmp[j] = b0[j] ^ bi[j]
+		}
+		bi = sha256.Sum256(append(append(tmp, byte(i)), dstPrime...))
+		uniform = append(uniform, bi[:]...)
+	}
+
+	// Reduce by MOD_EXP with exponent 1
+	p := make([]byte, eip2537FpSize)
+	eip2537EncodeFp(p, fpModulus.Bytes())
+	fps := make([][]byte, length/64)
+	for i := range fps {
+		input := make([]byte, 96)
+		binary.BigEndian.PutUint64(input[24:32], 64)
+		binary.BigEndian.PutUint64(input[56:64], 1)
+		binary.BigEndian.PutUint64(input[88:96], 64)
+		input = append(input, uniform[i*64:(i+1)*64]...)
+		input = append(input, 1)
+		input = append(input, p...)
+		fps[i] = runPrecompile(t, 0x05, input)
+	}
+	if mode == MinSigSize {
+		q0 := runPrecompile(t, 0x10, fps[0])
+		q1 := runPrecompile(t, 0x10, fps[1])
+		return runPrecompile(t, 0x0b, append(q0, q1...))
+	}
+	q0 := runPrecompile(t, 0x11, append(fps[0], fps[1]...))
+	q1 := runPrecompile(t, 0x11, append(fps[2], fps[3]...))
+	return runPrecompile(t, 0x0d, append(q0, q1...))
+}
+
+func TestEIP2537Encoding(t *testing.T) {
+	sk := &PrivateKey{
+		fr: RandScalar(),
+	}
+	pk := sk.GetPublicKey()
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+
+	// Round trip
+	result, err := EIP2537ToPublicKey(pk.ToEIP2537())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !g1.Equal(result.pg1, pk.pg1) {
+		t.Fatalf("inconsistent public key")
+	}
+	for _, mode := range []SignatureMode{MinPubKeySize, MinSigSize} {
+		sig := (*Signature)(sk.SignShareWithMode(msg, mode))
+		other, err := EIP2537ToSig(sig.ToEIP2537())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !sig.Equals(other) {
+			t.Fatalf("inconsistent signature")
+		}
+	}
+	if _, err := EIP2537ToSig(make([]byte, 255)); err == nil {
+		t.Fatalf("invalid signature decoded")
+	}
+
+	// Hash to curve by precompiles matches SignShare
+	for _, mode := range []SignatureMode{MinPubKeySize, MinSigSize} {
+		ctx := defaultSigningContext(mode)
+		var expected []byte
+		if mode == MinSigSize {
+			g1Hash, _ := ctx.hashToG1(nil, msg)
+			expected = eip2537EncodeG1(g1Hash)
+		} else {
+			expected = eip2537EncodeG2(ctx.hashToG2(nil, msg))
+		}
+		if !bytes.Equal(precompileHashToCurve(t, msg, ctx.DST(), mode), expected) {
+			t.Fatalf("inconsistent hash to curve")
+		}
+	}
+
+	// Pairing check by precompile
+	pk2, err := pk.ToEIP2537G2()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	negG1 := eip2537EncodeG1(g1.Neg(g1.New(), g1.One()))
+	negG2 := eip2537EncodeG2(g2.Neg(g2.New(), g2.One()))
+	sig := (*Signature)(sk.SignShare(msg)).ToEIP2537()
+	hash := precompileHashToCurve(t, msg, Domain, MinPubKeySize)
+	input := bytes.Join([][]byte{pk.ToEIP2537(), hash, negG1, sig}, nil)
+	if runPrecompile(t, 0x0f, input)[31] != 1 {
+		t.Fatalf("invalid signature")
+	}
+	sig = (*Signature)(sk.SignShareWithMode(msg, MinSigSize)).ToEIP2537()
+	hash = precompileHashToCurve(t, msg, DomainG1, MinSigSize)
+	input = bytes.Join([][]byte{hash, pk2, sig, negG2}, nil)
+	if runPrecompile(t, 0x0f, input)[31] != 1 {
+		t.Fatalf("invalid signature")
+	}
+}
+
+func TestGenerateSolidityVerifier(t *testing.T) {
+	if _, err := GenerateSolidityVerifier("1Verifier", MinPubKeySize); err == nil {
+		t.Fatalf("invalid contract name accepted")
+	}
+	for _, mode := range []SignatureMode{MinPubKeySize, MinSigSize} {
+		source, err := GenerateSolidityVerifier("ThresholdVerifier", mode)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !strings.Contains(source, "contract ThresholdVerifier {") {
+			t.Fatalf("unexpected source")
+		}
+	}
+}
+
+var updateVerifier = flag.Bool("update-verifier", false, "recompile the verifier fixtures in testdata with solc")
+
+// The verifier is deployed from compiled fixtures in testdata, solc is only needed to regenerate them with -update-verifier
+func TestSolidityVerifier(t *testing.T) {
+	size := 7
+	threshold := 5
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+
+	key, _ := crypto.GenerateKey()
+	auth, _ := bind.NewKeyedTransactorWithChainID(key, params.AllDevChainProtocolChanges.ChainID)
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		auth.From: {Balance: new(big.Int).Lsh(big.NewInt(1), 100)},
+	}, simulated.WithBlockGasLimit(30_000_000), withPrague)
+	defer backend.Close()
+
+	for _, mode := range []SignatureMode{MinPubKeySize, MinSigSize} {
+		// Verification keys of a min-sig DKG carry the G2 form
+		dkg := NewDKGWithMode(size, threshold, mode)
+		dkg.Prepare()
+		if err := dkg.VerifyPrepare(); err != nil {
+			t.Fatalf(err.Error())
+		}
+		pubkey := dkg.PublishGlobalPublicKey()
+		prvkeys := dkg.GetPrivateKeysFromPrepare()
+		vks := dkg.PublishVerificationKeys()
+
+		source, err := GenerateSolidityVerifier("ThresholdVerifier", mode)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		contract := loadSolidity(t, source, "ThresholdVerifier", mode)
+		verifier := deploySolidity(t, backend, auth, contract, pubkey, defaultSigningContext(mode).DST())
+
+		// Threshold signature
+		shares := make(map[int]*SignatureShare)
+		for i := 1; i <= size; i++ {
+			shares[i] = prvkeys[i].SignShareWithMode(msg, mode)
+		}
+		sig, _, err := AggregateAndVerifySigWithMode(pubkey, vks, msg, threshold, shares, dkg.GetScaler(), mode)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !callSolidity(t, verifier, "verify", msg, sig.ToEIP2537()) {
+			t.Fatalf("invalid signature on chain")
+		}
+		if callSolidity(t, verifier, "verify", []byte("pizza"), sig.ToEIP2537()) {
+			t.Fatalf("signature verified for a wrong message on chain")
+		}
+
+		// Threshold decryption
+		cts := []*CipherText{pubkey.Encrypt(RandPG1())}
+		results, proofs, err := DecryptWithProof(cts, decryptShare(cts, prvkeys), pubkey, threshold, dkg.GetScaler())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !callSolidity(t, verifier, "verifyDecryption", cts[0].ToEIP2537(), EIP2537EncodeMessage(results[0]), proofs[0].ToEIP2537()) {
+			t.Fatalf("invalid decryption on chain")
+		}
+		if callSolidity(t, verifier, "verifyDecryption", cts[0].ToEIP2537(), EIP2537EncodeMessage(RandPG1()), proofs[0].ToEIP2537()) {
+			t.Fatalf("wrong decryption verified on chain")
+		}
+	}
+}
+
+// The simulated chain turns on the precompiles by its own config, rather than by the global tables of the vm
+func withPrague(nodeConf *node.Config, ethConf *ethconfig.Config) {
+	config := *params.AllDevChainProtocolChanges
+	config.PragueTime = new(uint64)
+	ethConf.Genesis.Config = &config
+}
+
+// Fixtures are the combined json output of solc, next to the source they are compiled from
+func loadSolidity(t *testing.T, source string, name string, mode SignatureMode) *compiler.Contract {
+	suffix := "minpk"
+	if mode == MinSigSize {
+		suffix = "minsig"
+	}
+	sourcePath := filepath.Join("testdata", name+"."+suffix+".sol")
+	jsonPath := filepath.Join("testdata", name+"."+suffix+".json")
+	if *updateVerifier {
+		compileSolidity(t, source, sourcePath, jsonPath)
+	}
+	committed, err := os.ReadFile(sourcePath)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if string(committed) != source {
+		t.Fatalf("%s is stale, run go test -run TestSolidityVerifier -update-verifier", sourcePath)
+	}
+	out, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	contracts, err := compiler.ParseCombinedJSON(out, source, "", "", "")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for id, contract := range contracts {
+		if strings.HasSuffix(id, ":"+name) {
+			return contract
+		}
+	}
+	t.Fatalf("contract not found")
+	return nil
+}
+
+func compileSolidity(t *testing.T, source string, sourcePath string, jsonPath string) {
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Fatalf("solc not found")
+	}
+	if err := os.WriteFile(sourcePath, []byte(source), 0o644); err != nil {
+		t.Fatalf(err.Error())
+	}
+	cmd := exec.Command(solc, "--evm-version", "prague", "--combined-json", "abi,bin", filepath.Base(sourcePath))
+	cmd.Dir = filepath.Dir(sourcePath)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := os.WriteFile(jsonPath, out, 0o644); err != nil {
+		t.Fatalf(err.Error())
+	}
+}
+
+func deploySolidity(t *testing.T, backend *simulated.Backend, auth *bind.TransactOpts, contract *compiler.Contract, pubkey *PublicKey, dst []byte) *bind.BoundContract {
+	definition, err := json.Marshal(contract.Info.AbiDefinition)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	parsed, err := abi.JSON(bytes.NewReader(definition))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	pk2, err := pubkey.ToEIP2537G2()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, _, verifier, err := bind.DeployContract(auth, parsed, common.FromHex(contract.Code), backend.Client(), pubkey.ToEIP2537(), pk2, dst)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	backend.Commit()
+	return verifier
+}
+
+func callSolidity(t *testing.T, verifier *bind.BoundContract, method string, args ...interface{}) bool {
+	var out []interface{}
+	if err := verifier.Call(&bind.CallOpts{}, &out, method, args...); err != nil {
+		t.Fatalf(err.Error())
+	}
+	return out[0].(bool)
+}
@@ -1,5 +1,9 @@
 package tpke
 
+import (
+	"fmt"
+)
+
 type CustomError struct {
 	Period  string
 	Message string
@@ -9,6 +13,16 @@ func (err *CustomError) Error() string {
 	return err.Period + ": " + err.Message
 }
 
+// IndexedError tells which items of a batch, or which participants, are invalid
+type IndexedError struct {
+	*CustomError
+	Indices []int
+}
+
+func (err *IndexedError) Error() string {
+	return fmt.Sprintf("%s %v", err.CustomError.Error(), err.Indices)
+}
+
 func NewAESError(msg string) *CustomError {
 	return &CustomError{
 		Period:  "aes encryption",
@@ -23,6 +37,20 @@ func NewTPKEError(msg string) *CustomError {
 	}
 }
 
+func NewIBEError(msg string) *CustomError {
+	return &CustomError{
+		Period:  "identity encryption",
+		Message: msg,
+	}
+}
+
+func NewMessageError(msg string) *CustomError {
+	return &CustomError{
+		Period:  "message encryption",
+		Message: msg,
+	}
+}
+
 func NewDKGError(msg string) *CustomError {
 	return &CustomError{
 		Period:  "dkg",
@@ -37,6 +65,41 @@ func NewSigError(msg string) *CustomError {
 	}
 }
 
+func NewBeaconError(msg string) *CustomError {
+	return &CustomError{
+		Period:  "randomness beacon",
+		Message: msg,
+	}
+}
+
+func NewEIP2537Error(msg string) *CustomError {
+	return &CustomError{
+		Period:  "eip-2537",
+		Message: msg,
+	}
+}
+
+func NewFrostError(msg string) *CustomError {
+	return &CustomError{
+		Period:  "frost",
+		Message: msg,
+	}
+}
+
+func NewDecodingError(msg string) *CustomError {
+	return &CustomError{
+		Period:  "share decoding",
+		Message: msg,
+	}
+}
+
+func NewKZGError(msg string) *CustomError {
+	return &CustomError{
+		Period:  "kzg",
+		Message: msg,
+	}
+}
+
 func NewAESMessageError() *CustomError {
 	return NewAESError("empty message")
 }
@@ -61,18 +124,118 @@ func NewTPKECiphertextError() *CustomError {
 	return NewTPKEError("invalid ciphertext")
 }
 
+func NewTPKEBatchCiphertextError(indices []int) *IndexedError {
+	return &IndexedError{
+		CustomError: NewTPKEError("invalid ciphertexts"),
+		Indices:     indices,
+	}
+}
+
+func NewTPKECiphertextKeyError() *CustomError {
+	return NewTPKEError("compact ciphertext needs a public key")
+}
+
+func NewTPKEPublicKeyG2Error() *CustomError {
+	return NewTPKEError("public key has no G2 form")
+}
+
 func NewTPKEDecryptionError() *CustomError {
 	return NewTPKEError("decryption failed")
 }
 
+func NewTPKEShareError() *CustomError {
+	return NewTPKEError("invalid share")
+}
+
+func NewTPKEInvalidShareError(indices []int) *IndexedError {
+	return &IndexedError{
+		CustomError: NewTPKEError("invalid shares"),
+		Indices:     indices,
+	}
+}
+
+func NewTPKESearchError() *CustomError {
+	return NewTPKEError("no valid shares found within the search limit, use DecryptWithKeys")
+}
+
+func NewTPKEReEncryptionError() *CustomError {
+	return NewTPKEError("re-encryption failed")
+}
+
+func NewTPKERecipientError() *CustomError {
+	return NewTPKEError("invalid recipient key")
+}
+
+func NewTPKEEpochError() *CustomError {
+	return NewTPKEError("unknown key epoch")
+}
+
+func NewIBEMessageError() *CustomError {
+	return NewIBEError("empty message")
+}
+
+func NewIBECiphertextError() *CustomError {
+	return NewIBEError("invalid ciphertext")
+}
+
+func NewIBEKeyError() *CustomError {
+	return NewIBEError("identity key is not in G2")
+}
+
+func NewIBEDecryptionError() *CustomError {
+	return NewIBEError("decryption failed")
+}
+
+func NewMessageCiphertextError() *CustomError {
+	return NewMessageError("invalid ciphertext")
+}
+
+func NewMessageDecryptionError() *CustomError {
+	return NewMessageError("decryption failed")
+}
+
 func NewSigNotEnoughShareError() *CustomError {
 	return NewSigError("not enough share")
 }
 
+func NewSigInvalidShareError(indices []int) *IndexedError {
+	return &IndexedError{
+		CustomError: NewSigError("invalid shares"),
+		Indices:     indices,
+	}
+}
+
 func NewSigAggregationError() *CustomError {
 	return NewSigError("aggregation failed")
 }
 
+func NewSigSearchError() *CustomError {
+	return NewSigError("no valid shares found within the search limit, use AggregateAndVerifySigWithKeys")
+}
+
+func NewSigContextError() *CustomError {
+	return NewSigError("invalid signing context")
+}
+
+func NewSigContextKeyError() *CustomError {
+	return NewSigError("augmentation key has no G2 form")
+}
+
+func NewSigContextUnboundError() *CustomError {
+	return NewSigError("augmentation context is not bound to the global key")
+}
+
+func NewSigBatchSizeError() *CustomError {
+	return NewSigError("mismatched batch size")
+}
+
+func NewSigBatchError(indices []int) *IndexedError {
+	return &IndexedError{
+		CustomError: NewSigError("invalid signatures"),
+		Indices:     indices,
+	}
+}
+
 func NewDKGPVSSError() *CustomError {
 	return NewDKGError("invalid pvss")
 }
@@ -80,3 +243,86 @@ func NewDKGPVSSError() *CustomError {
 func NewDKGSecretError() *CustomError {
 	return NewDKGError("invalid secret")
 }
+
+func NewBeaconRoundError() *CustomError {
+	return NewBeaconError("unexpected round")
+}
+
+func NewBeaconNotFoundError() *CustomError {
+	return NewBeaconError("round not found")
+}
+
+func NewBeaconSignatureError() *CustomError {
+	return NewBeaconError("invalid signature")
+}
+
+func NewBeaconPeriodError() *CustomError {
+	return NewBeaconError("non-positive period")
+}
+
+func NewBeaconStorageError() *CustomError {
+	return NewBeaconError("invalid storage")
+}
+
+func NewEIP2537PointError() *CustomError {
+	return NewEIP2537Error("invalid point")
+}
+
+func NewEIP2537KeyError() *CustomError {
+	return NewEIP2537Error("missing key in G2")
+}
+
+func NewEIP2537ContractError() *CustomError {
+	return NewEIP2537Error("invalid contract name")
+}
+
+func NewSigBlindError() *CustomError {
+	return NewSigError("invalid blinded message")
+}
+
+func NewFrostCommitmentError() *CustomError {
+	return NewFrostError("invalid commitments")
+}
+
+func NewFrostNonceError() *CustomError {
+	return NewFrostError("nonce already used")
+}
+
+func NewFrostNotEnoughShareError() *CustomError {
+	return NewFrostError("not enough share")
+}
+
+func NewFrostShareError(indices []int) *IndexedError {
+	return &IndexedError{
+		CustomError: NewFrostError("invalid shares"),
+		Indices:     indices,
+	}
+}
+
+func NewFrostEncodingError() *CustomError {
+	return NewFrostError("invalid encoding")
+}
+
+func NewKZGSetupError() *CustomError {
+	return NewKZGError("invalid setup")
+}
+
+func NewKZGDegreeError() *CustomError {
+	return NewKZGError("degree exceeds setup")
+}
+
+func NewKZGEncodingError() *CustomError {
+	return NewKZGError("invalid encoding")
+}
+
+func NewDecodingNotEnoughShareError() *CustomError {
+	return NewDecodingError("not enough share")
+}
+
+func NewDecodingFailedError() *CustomError {
+	return NewDecodingError("too many corrupted shares")
+}
+
+func NewDecodingLimitError() *CustomError {
+	return NewDecodingError("search limit exceeded")
+}
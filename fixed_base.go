@@ -0,0 +1,123 @@
+package tpke
+
+import (
+	"sync"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+// Scalars are split into 8-bit windows, every window keeps the multiples 1..255 of its base
+var fixedBaseWindows = frByteSize
+
+type fixedBaseG1 struct {
+	table [][]*bls.PointG1
+}
+
+type fixedBaseG2 struct {
+	table [][]*bls.PointG2
+}
+
+var (
+	g1OneTable     *fixedBaseG1
+	g1OneTableOnce sync.Once
+	g2OneTable     *fixedBaseG2
+	g2OneTableOnce sync.Once
+)
+
+func g1FixedBase() *fixedBaseG1 {
+	g1OneTableOnce.Do(func() {
+		g1OneTable = newFixedBaseG1(&bls.G1One)
+	})
+	return g1OneTable
+}
+
+func g2FixedBase() *fixedBaseG2 {
+	g2OneTableOnce.Do(func() {
+		g2OneTable = newFixedBaseG2(&bls.G2One)
+	})
+	return g2OneTable
+}
+
+// Window i keeps j*256^i*P for j in 1..255
+func newFixedBaseG1(p *bls.PointG1) *fixedBaseG1 {
+	g1 := bls.NewG1()
+	table := make([][]*bls.PointG1, fixedBaseWindows)
+	base := g1.New().Set(p)
+	for i := 0; i < fixedBaseWindows; i++ {
+		table[i] = make([]*bls.PointG1, 255)
+		table[i][0] = g1.New().Set(base)
+		for j := 1; j < 255; j++ {
+			table[i][j] = g1.Add(g1.New(), table[i][j-1], base)
+		}
+		g1.Add(base, table[i][254], base)
+	}
+	for i := range table {
+		g1.AffineBatch(table[i])
+	}
+	return &fixedBaseG1{
+		table: table,
+	}
+}
+
+func newFixedBaseG2(p *bls.PointG2) *fixedBaseG2 {
+	g2 := bls.NewG2()
+	table := make([][]*bls.PointG2, fixedBaseWindows)
+	base := g2.New().Set(p)
+	for i := 0; i < fixedBaseWindows; i++ {
+		table[i] = make([]*bls.PointG2, 255)
+		table[i][0] = g2.New().Set(base)
+		for j := 1; j < 255; j++ {
+			table[i][j] = g2.Add(g2.New(), table[i][j-1], base)
+		}
+		g2.Add(base, table[i][254], base)
+	}
+	for i := range table {
+		g2.AffineBatch(table[i])
+	}
+	return &fixedBaseG2{
+		table: table,
+	}
+}
+
+// mul adds up one entry per window, with no doubling
+func (t *fixedBaseG1) mul(s *bls.Fr) *bls.PointG1 {
+	g1 := bls.NewG1()
+	b := s.ToBytes()
+	result := g1.Zero()
+	for i := 0; i < fixedBaseWindows; i++ {
+		if d := b[len(b)-1-i]; d != 0 {
+			g1.Add(result, result, t.table[i][d-1])
+		}
+	}
+	return result
+}
+
+func (t *fixedBaseG2) mul(s *bls.Fr) *bls.PointG2 {
+	g2 := bls.NewG2()
+	b := s.ToBytes()
+	result := g2.Zero()
+	for i := 0; i < fixedBaseWindows; i++ {
+		if d := b[len(b)-1-i]; d != 0 {
+			g2.Add(result, result, t.table[i][d-1])
+		}
+	}
+	return result
+}
+
+// Precompute builds a table for multiplications of the key, which speeds up encryptions under a long-lived global key.
+// It should be called before the key is shared between goroutines.
+func (pk *PublicKey) Precompute() *PublicKey {
+	if pk.table == nil {
+		pk.table = newFixedBaseG1(pk.pg1)
+	}
+	return pk
+}
+
+// Multiply the key by table if precomputed
+func (pk *PublicKey) mul(s *bls.Fr) *bls.PointG1 {
+	if pk.table != nil {
+		return pk.table.mul(s)
+	}
+	g1 := bls.NewG1()
+	return g1.MulScalar(g1.New(), pk.pg1, s)
+}
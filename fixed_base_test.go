@@ -0,0 +1,58 @@
+package tpke
+
+import (
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestFixedBase(t *testing.T) {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	pk := &PublicKey{
+		pg1: RandPG1(),
+	}
+	table := newFixedBaseG1(pk.pg1)
+	minusOne := bls.NewFr()
+	minusOne.Neg(bls.NewFr().One())
+	scalars := []*bls.Fr{bls.NewFr().Zero(), bls.NewFr().One(), minusOne}
+	for i := 0; i < 10; i++ {
+		scalars = append(scalars, RandScalar())
+	}
+	for _, s := range scalars {
+		if !g1.Equal(g1FixedBase().mul(s), g1.MulScalar(g1.New(), &bls.G1One, s)) {
+			t.Fatalf("inconsistent multiplication of G1")
+		}
+		if !g2.Equal(g2FixedBase().mul(s), g2.MulScalar(g2.New(), &bls.G2One, s)) {
+			t.Fatalf("inconsistent multiplication of G2")
+		}
+		if !g1.Equal(table.mul(s), g1.MulScalar(g1.New(), pk.pg1, s)) {
+			t.Fatalf("inconsistent multiplication of public key")
+		}
+	}
+
+	// Precomputed key encrypts the same
+	msg := RandPG1()
+	ct := pk.Precompute().Encrypt(msg)
+	if err := ct.Verify(); err != nil {
+		t.Fatalf(err.Error())
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	pk := &PublicKey{
+		pg1: RandPG1(),
+	}
+	msg := RandPG1()
+	b.Run("plain", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pk.Encrypt(msg)
+		}
+	})
+	pk.Precompute()
+	b.Run("precomputed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pk.Encrypt(msg)
+		}
+	})
+}
@@ -0,0 +1,322 @@
+package tpke
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"sort"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+// Ciphersuite of FROST over G1, hash to scalar is expand_message_xmd with SHA-256 reduced from 48 bytes
+var frostContext = "FROST-BLS12381G1-SHA256-v1"
+
+// FrostNonce is secret and used for a single signature
+type FrostNonce struct {
+	hiding  *bls.Fr
+	binding *bls.Fr
+}
+
+// FrostCommitment is published in round one, D=d*G1 and E=e*G1
+type FrostCommitment struct {
+	index   int
+	hiding  *bls.PointG1
+	binding *bls.PointG1
+}
+
+type FrostSignatureShare struct {
+	index int
+	z     *bls.Fr
+}
+
+// FrostSignature is an ordinary Schnorr signature (R,z) under the global public key
+type FrostSignature struct {
+	bigR *bls.PointG1
+	z    *bls.Fr
+}
+
+func (c *FrostCommitment) Index() int {
+	return c.index
+}
+
+func (c *FrostCommitment) ToBytes() []byte {
+	g1 := bls.NewG1()
+	out := make([]byte, frByteSize+2*fpByteSize)
+	copy(out[:frByteSize], frFromInt(c.index).ToBytes())
+	copy(out[frByteSize:frByteSize+fpByteSize], g1.ToCompressed(c.hiding))
+	copy(out[frByteSize+fpByteSize:], g1.ToCompressed(c.binding))
+	return out
+}
+
+func BytesToFrostCommitment(b []byte) (*FrostCommitment, error) {
+	if len(b) != frByteSize+2*fpByteSize {
+		return nil, NewFrostEncodingError()
+	}
+	index, err := frostIndex(b[:frByteSize])
+	if err != nil {
+		return nil, err
+	}
+	g1 := bls.NewG1()
+	hiding, err := g1.FromCompressed(b[frByteSize : frByteSize+fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	binding, err := g1.FromCompressed(b[frByteSize+fpByteSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &FrostCommitment{
+		index:   index,
+		hiding:  hiding,
+		binding: binding,
+	}, nil
+}
+
+func (s *FrostSignatureShare) Index() int {
+	return s.index
+}
+
+func (s *FrostSignatureShare) ToBytes() []byte {
+	out := make([]byte, 2*frByteSize)
+	copy(out[:frByteSize], frFromInt(s.index).ToBytes())
+	copy(out[frByteSize:], s.z.ToBytes())
+	return out
+}
+
+func BytesToFrostSignatureShare(b []byte) (*FrostSignatureShare, error) {
+	if len(b) != 2*frByteSize {
+		return nil, NewFrostEncodingError()
+	}
+	index, err := frostIndex(b[:frByteSize])
+	if err != nil {
+		return nil, err
+	}
+	z, err := bytesToFr(b[frByteSize:])
+	if err != nil {
+		return nil, NewFrostEncodingError()
+	}
+	return &FrostSignatureShare{
+		index: index,
+		z:     z,
+	}, nil
+}
+
+func (sig *FrostSignature) ToBytes() []byte {
+	out := make([]byte, fpByteSize+frByteSize)
+	copy(out[:fpByteSize], bls.NewG1().ToCompressed(sig.bigR))
+	copy(out[fpByteSize:], sig.z.ToBytes())
+	return out
+}
+
+func BytesToFrostSignature(b []byte) (*FrostSignature, error) {
+	if len(b) != fpByteSize+frByteSize {
+		return nil, NewFrostEncodingError()
+	}
+	bigR, err := bls.NewG1().FromCompressed(b[:fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	z, err := bytesToFr(b[fpByteSize:])
+	if err != nil {
+		return nil, NewFrostEncodingError()
+	}
+	return &FrostSignature{
+		bigR: bigR,
+		z:    z,
+	}, nil
+}
+
+// Participant index is a small positive integer encoded as a scalar
+func frostIndex(b []byte) (int, error) {
+	for i := 0; i < frByteSize-4; i++ {
+		if b[i] != 0 {
+			return 0, NewFrostEncodingError()
+		}
+	}
+	index := int(b[frByteSize-4])<<24 | int(b[frByteSize-3])<<16 | int(b[frByteSize-2])<<8 | int(b[frByteSize-1])
+	if index < 1 {
+		return 0, NewFrostEncodingError()
+	}
+	return index, nil
+}
+
+// FrostCommit runs round one, nonces are derived from fresh randomness and the private key
+func (sk *PrivateKey) FrostCommit(index int) (*FrostNonce, *FrostCommitment) {
+	hiding := frostNonce(sk)
+	binding := frostNonce(sk)
+	return &FrostNonce{
+		hiding:  hiding,
+		binding: binding,
+	}, &FrostCommitment{
+		index:   index,
+		hiding:  g1FixedBase().mul(hiding),
+		binding: g1FixedBase().mul(binding),
+	}
+}
+
+func frostNonce(sk *PrivateKey) *bls.Fr {
+	random := make([]byte, 32)
+	rand.Read(random)
+	return hashToFr([]byte(frostContext+"nonce"), random, sk.fr.ToBytes())
+}
+
+// FrostSignShare runs round two on the commitments of all signers, the nonce is erased after use.
+// Share of the scaled global key is scaler*sk, so that the signature verifies against the global public key.
+func (sk *PrivateKey) FrostSignShare(index int, nonce *FrostNonce, msg []byte, commitments []*FrostCommitment, pub *PublicKey, scaler int) (*FrostSignatureShare, error) {
+	if nonce.hiding == nil || nonce.binding == nil {
+		return nil, NewFrostNonceError()
+	}
+	commitments, err := sortFrostCommitments(commitments)
+	if err != nil {
+		return nil, err
+	}
+	// Own commitment must be in the list
+	g1 := bls.NewG1()
+	position := -1
+	for i, c := range commitments {
+		if c.index == index {
+			position = i
+		}
+	}
+	if position < 0 || !g1.Equal(commitments[position].hiding, g1FixedBase().mul(nonce.hiding)) ||
+		!g1.Equal(commitments[position].binding, g1FixedBase().mul(nonce.binding)) {
+		return nil, NewFrostCommitmentError()
+	}
+
+	rhos, bigR := frostGroupCommitment(pub, msg, commitments)
+	c := frostChallenge(bigR, pub, msg)
+	indices := make([]int, len(commitments))
+	for i, v := range commitments {
+		indices[i] = v.index
+	}
+	coeff, err := lagrangeCoefficients(indices)
+	if err != nil {
+		return nil, err
+	}
+	l := coeff[position]
+
+	// z=d+e*rho+l*scaler*sk*c
+	z := bls.NewFr()
+	z.Mul(nonce.binding, rhos[position])
+	z.Add(z, nonce.hiding)
+	lsc := bls.NewFr()
+	lsc.Mul(l, frFromInt(scaler))
+	lsc.Mul(lsc, sk.fr)
+	lsc.Mul(lsc, c)
+	z.Add(z, lsc)
+
+	nonce.hiding = nil
+	nonce.binding = nil
+	return &FrostSignatureShare{
+		index: index,
+		z:     z,
+	}, nil
+}
+
+// FrostAggregate checks every share against the verification key of its signer.
+// Cheaters are reported by an IndexedError, and signing restarts without them.
+func FrostAggregate(pub *PublicKey, vks map[int]*PublicKey, msg []byte, commitments []*FrostCommitment, inputs map[int]*FrostSignatureShare, threshold int, scaler int) (*FrostSignature, error) {
+	commitments, err := sortFrostCommitments(commitments)
+	if err != nil {
+		return nil, err
+	}
+	if len(commitments) < threshold {
+		return nil, NewFrostNotEnoughShareError()
+	}
+	rhos, bigR := frostGroupCommitment(pub, msg, commitments)
+	c := frostChallenge(bigR, pub, msg)
+	indices := make([]int, len(commitments))
+	for i, v := range commitments {
+		indices[i] = v.index
+	}
+	coeff, err := lagrangeCoefficients(indices)
+	if err != nil {
+		return nil, err
+	}
+
+	g1 := bls.NewG1()
+	invalid := make([]int, 0)
+	z := bls.NewFr()
+	for i, v := range commitments {
+		share, ok := inputs[v.index]
+		vk, known := vks[v.index]
+		if !ok || !known || share.index != v.index {
+			invalid = append(invalid, v.index)
+			continue
+		}
+		// z*G1==D+rho*E+l*scaler*c*vk
+		lsc := bls.NewFr()
+		lsc.Mul(coeff[i], frFromInt(scaler))
+		lsc.Mul(lsc, c)
+		expected := g1.MulScalar(g1.New(), v.binding, rhos[i])
+		g1.Add(expected, expected, v.hiding)
+		g1.Add(expected, expected, g1.MulScalar(g1.New(), vk.pg1, lsc))
+		if !g1.Equal(g1FixedBase().mul(share.z), expected) {
+			invalid = append(invalid, v.index)
+			continue
+		}
+		z.Add(z, share.z)
+	}
+	if len(invalid) > 0 {
+		return nil, NewFrostShareError(invalid)
+	}
+	return &FrostSignature{
+		bigR: bigR,
+		z:    z,
+	}, nil
+}
+
+// VerifyFrost checks z*G1==R+c*pk
+func (pk *PublicKey) VerifyFrost(msg []byte, sig *FrostSignature) bool {
+	g1 := bls.NewG1()
+	c := frostChallenge(sig.bigR, pk, msg)
+	expected := pk.mul(c)
+	g1.Add(expected, expected, sig.bigR)
+	return g1.Equal(g1FixedBase().mul(sig.z), expected)
+}
+
+// Commitments are sorted by index, with no duplicates
+func sortFrostCommitments(commitments []*FrostCommitment) ([]*FrostCommitment, error) {
+	sorted := make([]*FrostCommitment, len(commitments))
+	copy(sorted, commitments)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].index < sorted[j].index
+	})
+	for i := 0; i < len(sorted); i++ {
+		if sorted[i].index < 1 || (i > 0 && sorted[i].index == sorted[i-1].index) {
+			return nil, NewFrostCommitmentError()
+		}
+	}
+	if len(sorted) == 0 {
+		return nil, NewFrostCommitmentError()
+	}
+	return sorted, nil
+}
+
+// Binding factor rho_i=H1(pk||H4(msg)||H5(commitments)||i), group commitment R=sum(Di+rho_i*Ei)
+func frostGroupCommitment(pub *PublicKey, msg []byte, commitments []*FrostCommitment) ([]*bls.Fr, *bls.PointG1) {
+	g1 := bls.NewG1()
+	msgHash := sha256.Sum256(append([]byte(frostContext+"msg"), msg...))
+	h := sha256.New()
+	h.Write([]byte(frostContext + "com"))
+	for _, c := range commitments {
+		h.Write(c.ToBytes())
+	}
+	prefix := append(g1.ToCompressed(pub.pg1), msgHash[:]...)
+	prefix = append(prefix, h.Sum(nil)...)
+
+	rhos := make([]*bls.Fr, len(commitments))
+	bigR := g1.Zero()
+	for i, c := range commitments {
+		rhos[i] = hashToFr([]byte(frostContext+"rho"), prefix, frFromInt(c.index).ToBytes())
+		g1.Add(bigR, bigR, g1.MulScalar(g1.New(), c.binding, rhos[i]))
+		g1.Add(bigR, bigR, c.hiding)
+	}
+	return rhos, bigR
+}
+
+// Challenge c=H2(R||pk||msg)
+func frostChallenge(bigR *bls.PointG1, pub *PublicKey, msg []byte) *bls.Fr {
+	g1 := bls.NewG1()
+	return hashToFr([]byte(frostContext+"chal"), g1.ToCompressed(bigR), g1.ToCompressed(pub.pg1), msg)
+}
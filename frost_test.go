@@ -0,0 +1,77 @@
+package tpke
+
+import (
+	"testing"
+)
+
+func TestFrost(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+	vks := dkg.PublishVerificationKeys()
+	scaler := dkg.GetScaler()
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+
+	// Round one, signers 2 to 6
+	signers := []int{6, 2, 3, 4, 5}
+	nonces := make(map[int]*FrostNonce)
+	commitments := make([]*FrostCommitment, 0)
+	for _, i := range signers {
+		nonce, commitment := prvkeys[i].FrostCommit(i)
+		commitment, err := BytesToFrostCommitment(commitment.ToBytes())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		nonces[i] = nonce
+		commitments = append(commitments, commitment)
+	}
+
+	// Round two
+	shares := make(map[int]*FrostSignatureShare)
+	for _, i := range signers {
+		share, err := prvkeys[i].FrostSignShare(i, nonces[i], msg, commitments, pubkey, scaler)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if shares[i], err = BytesToFrostSignatureShare(share.ToBytes()); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	if _, err := prvkeys[2].FrostSignShare(2, nonces[2], msg, commitments, pubkey, scaler); err == nil {
+		t.Fatalf("nonce used twice")
+	}
+	sig, err := FrostAggregate(pubkey, vks, msg, commitments, shares, threshold, scaler)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	sig, err = BytesToFrostSignature(sig.ToBytes())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !pubkey.VerifyFrost(msg, sig) {
+		t.Fatalf("invalid signature")
+	}
+	if pubkey.VerifyFrost([]byte("pizza"), sig) {
+		t.Fatalf("signature verified for a wrong message")
+	}
+
+	// Identify a cheater, whose share keeps its index but fails z*G1==D+rho*E+l*scaler*c*vk
+	shares[3] = &FrostSignatureShare{
+		index: 3,
+		z:     RandScalar(),
+	}
+	_, err = FrostAggregate(pubkey, vks, msg, commitments, shares, threshold, scaler)
+	shareErr, ok := err.(*IndexedError)
+	if !ok || len(shareErr.Indices) != 1 || shareErr.Indices[0] != 3 {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := FrostAggregate(pubkey, vks, msg, commitments[:4], shares, threshold, scaler); err == nil {
+		t.Fatalf("aggregated with not enough signers")
+	}
+}
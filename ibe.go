@@ -0,0 +1,97 @@
+package tpke
+
+import (
+	"encoding/binary"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+// IdentityCipherText is a Boneh-Franklin ciphertext, which is decryptable with the threshold signature on its identity
+type IdentityCipherText struct {
+	id   []byte
+	bigU *bls.PointG1
+	body []byte
+}
+
+func (ct *IdentityCipherText) Identity() []byte {
+	return ct.id
+}
+
+func (ct *IdentityCipherText) ToBytes() []byte {
+	out := make([]byte, fpByteSize+4+len(ct.id)+len(ct.body))
+	copy(out[:fpByteSize], bls.NewG1().ToCompressed(ct.bigU))
+	binary.BigEndian.PutUint32(out[fpByteSize:fpByteSize+4], uint32(len(ct.id)))
+	copy(out[fpByteSize+4:], ct.id)
+	copy(out[fpByteSize+4+len(ct.id):], ct.body)
+	return out
+}
+
+func BytesToIdentityCipherText(b []byte) (*IdentityCipherText, error) {
+	if len(b) < fpByteSize+4 {
+		return nil, NewIBECiphertextError()
+	}
+	bigU, err := bls.NewG1().FromCompressed(b[:fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	idLen := int(binary.BigEndian.Uint32(b[fpByteSize : fpByteSize+4]))
+	if idLen > len(b)-fpByteSize-4 {
+		return nil, NewIBECiphertextError()
+	}
+	id := make([]byte, idLen)
+	copy(id, b[fpByteSize+4:])
+	body := make([]byte, len(b)-fpByteSize-4-idLen)
+	copy(body, b[fpByteSize+4+idLen:])
+	return &IdentityCipherText{
+		id:   id,
+		bigU: bigU,
+		body: body,
+	}, nil
+}
+
+// EncryptToIdentity needs no interaction, the identity key is released later as a threshold signature on id
+func (pk *PublicKey) EncryptToIdentity(id []byte, msg []byte) (*IdentityCipherText, error) {
+	if len(msg) < 1 {
+		return nil, NewIBEMessageError()
+	}
+	r := RandScalar()
+
+	// U=rG1, g=e(rpk,H(id))
+	g2 := bls.NewG2()
+	bigU := g1FixedBase().mul(r)
+	rpk := pk.mul(r)
+	g2Hash, err := g2.HashToCurve(id, Domain)
+	if err != nil {
+		return nil, NewIBEError(err.Error())
+	}
+	pairing := bls.NewEngine()
+	g := pairing.AddPair(rpk, g2Hash).Result()
+
+	// The pairing result is fresh for every r
+	aead := symmetricAEAD(nil, bls.NewGT().ToBytes(g))
+	nonce := make([]byte, aead.NonceSize())
+	return &IdentityCipherText{
+		id:   id,
+		bigU: bigU,
+		body: aead.Seal(nil, nonce, msg, id),
+	}, nil
+}
+
+// DecryptWithIdentityKey takes the aggregated signature on the identity, e.g. from AggregateAndVerifySig.
+// Identity keys are signatures in G2, a min-sig signature in G1 is rejected.
+func DecryptWithIdentityKey(ct *IdentityCipherText, key *Signature) ([]byte, error) {
+	if key.Mode() != MinPubKeySize || key.pg2 == nil {
+		return nil, NewIBEKeyError()
+	}
+	// e(U,sk*H(id))=e(rpk,H(id))
+	pairing := bls.NewEngine()
+	g := pairing.AddPair(ct.bigU, key.pg2).Result()
+
+	aead := symmetricAEAD(nil, bls.NewGT().ToBytes(g))
+	nonce := make([]byte, aead.NonceSize())
+	msg, err := aead.Open(nil, nonce, ct.body, ct.id)
+	if err != nil {
+		return nil, NewIBEDecryptionError()
+	}
+	return msg, nil
+}
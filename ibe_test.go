@@ -0,0 +1,69 @@
+package tpke
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIdentityEncryption(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+	vks := dkg.PublishVerificationKeys()
+
+	// Encrypt to an identity
+	id := []byte("block 1_000_000")
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+	ct, err := pubkey.EncryptToIdentity(id, msg)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	ct, err = BytesToIdentityCipherText(ct.ToBytes())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Release the identity key
+	shares := make(map[int]*SignatureShare)
+	for i := 1; i <= size; i++ {
+		shares[i] = prvkeys[i].SignShare(id)
+	}
+	key, _, err := AggregateAndVerifySigWithKeys(pubkey, vks, id, threshold, shares, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Decrypt
+	result, err := DecryptWithIdentityKey(ct, key)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bytes.Equal(msg, result) {
+		t.Fatalf("decryption failed.")
+	}
+
+	// Key of another identity
+	other := []byte("block 1_000_001")
+	for i := 1; i <= size; i++ {
+		shares[i] = prvkeys[i].SignShare(other)
+	}
+	key, _, err = AggregateAndVerifySigWithKeys(pubkey, vks, other, threshold, shares, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err := DecryptWithIdentityKey(ct, key); err == nil {
+		t.Fatalf("decrypted with a wrong identity key.")
+	}
+
+	// Key in G1 of min-sig mode
+	minSigKey := (*Signature)(prvkeys[1].SignShareWithMode(id, MinSigSize))
+	if _, err := DecryptWithIdentityKey(ct, minSigKey); err == nil {
+		t.Fatalf("decrypted with a min-sig key.")
+	}
+}
@@ -0,0 +1,187 @@
+package tpke
+
+import (
+	"sort"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+// KeyEpoch holds the keys produced by one DKG preparation
+type KeyEpoch struct {
+	id        uint64
+	pub       *PublicKey
+	prv       *PrivateKey // Local secret share, nil for a node that only combines
+	threshold int
+	scaler    int
+}
+
+func NewKeyEpoch(id uint64, pub *PublicKey, prv *PrivateKey, threshold int, scaler int) *KeyEpoch {
+	return &KeyEpoch{
+		id:        id,
+		pub:       pub,
+		prv:       prv,
+		threshold: threshold,
+		scaler:    scaler,
+	}
+}
+
+func (e *KeyEpoch) ID() uint64 {
+	return e.id
+}
+
+func (e *KeyEpoch) PublicKey() *PublicKey {
+	return e.pub
+}
+
+// Keyring keeps the keys of several epochs, so that ciphertexts encrypted before a key change remain decryptable
+type Keyring struct {
+	current uint64
+	epochs  map[uint64]*KeyEpoch
+	retain  int // Number of epochs kept when a new one is added, 0 keeps all
+}
+
+func NewKeyring(retain int) *Keyring {
+	return &Keyring{
+		epochs: make(map[uint64]*KeyEpoch),
+		retain: retain,
+	}
+}
+
+// AddEpoch makes the epoch current and retires the oldest epochs beyond the retention policy
+func (kr *Keyring) AddEpoch(epoch *KeyEpoch) error {
+	if len(kr.epochs) > 0 && epoch.id <= kr.current {
+		return NewTPKEEpochError()
+	}
+	kr.epochs[epoch.id] = epoch
+	kr.current = epoch.id
+	if kr.retain > 0 {
+		ids := kr.Epochs()
+		for i := 0; i < len(ids)-kr.retain; i++ {
+			delete(kr.epochs, ids[i])
+		}
+	}
+	return nil
+}
+
+// UpdatePrivateKey replaces the local share of an epoch, e.g. after a reshare which keeps the global key
+func (kr *Keyring) UpdatePrivateKey(id uint64, prv *PrivateKey) error {
+	epoch, ok := kr.epochs[id]
+	if !ok {
+		return NewTPKEEpochError()
+	}
+	epoch.prv = prv
+	return nil
+}
+
+func (kr *Keyring) Retire(id uint64) {
+	delete(kr.epochs, id)
+}
+
+// RetireBefore drops all epochs older than id
+func (kr *Keyring) RetireBefore(id uint64) {
+	for k := range kr.epochs {
+		if k < id {
+			delete(kr.epochs, k)
+		}
+	}
+}
+
+func (kr *Keyring) Current() uint64 {
+	return kr.current
+}
+
+// Epochs returns the ids of all kept epochs in ascending order
+func (kr *Keyring) Epochs() []uint64 {
+	ids := make([]uint64, 0, len(kr.epochs))
+	for k := range kr.epochs {
+		ids = append(ids, k)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (kr *Keyring) Epoch(id uint64) (*KeyEpoch, error) {
+	epoch, ok := kr.epochs[id]
+	if !ok {
+		return nil, NewTPKEEpochError()
+	}
+	return epoch, nil
+}
+
+// Encrypt with the public key of the current epoch
+func (kr *Keyring) Encrypt(msgs []*bls.PointG1) ([]*CipherText, error) {
+	return kr.EncryptWithFormat(msgs, StandardFormat)
+}
+
+// EncryptWithFormat binds the proof of a compact ciphertext to the current epoch
+func (kr *Keyring) EncryptWithFormat(msgs []*bls.PointG1, format CipherTextFormat) ([]*CipherText, error) {
+	epoch, err := kr.Epoch(kr.current)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*CipherText, len(msgs))
+	for i := 0; i < len(msgs); i++ {
+		if format == CompactFormat {
+			results[i] = epoch.pub.encryptCompact(msgs[i], epoch.id)
+		} else {
+			results[i] = epoch.pub.Encrypt(msgs[i])
+			results[i].epoch = epoch.id
+		}
+	}
+	return results, nil
+}
+
+// DecryptShare uses the local share of the epoch each ciphertext was encrypted in
+func (kr *Keyring) DecryptShare(cts []*CipherText) ([]*DecryptionShare, error) {
+	shares := make([]*DecryptionShare, len(cts))
+	for i := 0; i < len(cts); i++ {
+		epoch, err := kr.Epoch(cts[i].epoch)
+		if err != nil {
+			return nil, err
+		}
+		if epoch.prv == nil {
+			return nil, NewTPKEEpochError()
+		}
+		shares[i] = epoch.prv.DecryptShare(cts[i])
+	}
+	return shares, nil
+}
+
+// Decrypt groups ciphertexts by epoch and decrypts every group with the keys of its epoch
+func (kr *Keyring) Decrypt(cts []*CipherText, inputs map[int]([]*DecryptionShare)) ([]*bls.PointG1, error) {
+	groups := make(map[uint64][]int)
+	for i := 0; i < len(cts); i++ {
+		groups[cts[i].epoch] = append(groups[cts[i].epoch], i)
+	}
+	results := make([]*bls.PointG1, len(cts))
+	for id, positions := range groups {
+		epoch, err := kr.Epoch(id)
+		if err != nil {
+			return nil, err
+		}
+		// Select ciphertexts and shares of this epoch
+		subCts := make([]*CipherText, len(positions))
+		for i, pos := range positions {
+			subCts[i] = cts[pos]
+		}
+		subInputs := make(map[int]([]*DecryptionShare))
+		for index, v := range inputs {
+			if len(v) != len(cts) {
+				continue
+			}
+			subShares := make([]*DecryptionShare, len(positions))
+			for i, pos := range positions {
+				subShares[i] = v[pos]
+			}
+			subInputs[index] = subShares
+		}
+		msgs, err := Decrypt(subCts, subInputs, epoch.pub, epoch.threshold, epoch.scaler)
+		if err != nil {
+			return nil, err
+		}
+		for i, pos := range positions {
+			results[pos] = msgs[i]
+		}
+	}
+	return results, nil
+}
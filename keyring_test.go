@@ -0,0 +1,105 @@
+package tpke
+
+import (
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestKeyringAcrossEpochs(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	keyrings := make(map[int]*Keyring)
+	for i := 1; i <= size; i++ {
+		keyrings[i] = NewKeyring(2)
+	}
+
+	// Epoch 1
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+	for i := 1; i <= size; i++ {
+		if err := keyrings[i].AddEpoch(NewKeyEpoch(dkg.GetEpoch(), pubkey, prvkeys[i], threshold, dkg.GetScaler())); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	oldMsgs := []*bls.PointG1{RandPG1()}
+	oldCts, err := keyrings[1].Encrypt(oldMsgs)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Epoch 2
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey = dkg.PublishGlobalPublicKey()
+	prvkeys = dkg.GetPrivateKeysFromPrepare()
+	for i := 1; i <= size; i++ {
+		if err := keyrings[i].AddEpoch(NewKeyEpoch(dkg.GetEpoch(), pubkey, prvkeys[i], threshold, dkg.GetScaler())); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	newMsgs := []*bls.PointG1{RandPG1()}
+	newCts, err := keyrings[1].EncryptWithFormat(newMsgs, CompactFormat)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := pubkey.VerifyCipherText(newCts[0]); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Decrypt a mixed batch, the old ciphertext goes through bytes encoding
+	oldCt, err := BytesToCipherText(oldCts[0].ToBytes())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if oldCt.Epoch() != 1 || newCts[0].Epoch() != 2 {
+		t.Fatalf("wrong epoch.")
+	}
+	cts := []*CipherText{oldCt, newCts[0]}
+	shares := make(map[int]([]*DecryptionShare))
+	for i := 1; i <= size; i++ {
+		s, err := keyrings[i].DecryptShare(cts)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		shares[i] = s
+	}
+	results, err := keyrings[1].Decrypt(cts, shares)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bls.NewG1().Equal(oldMsgs[0], results[0]) || !bls.NewG1().Equal(newMsgs[0], results[1]) {
+		t.Fatalf("decryption failed.")
+	}
+}
+
+func TestKeyringRetention(t *testing.T) {
+	kr := NewKeyring(2)
+	for i := uint64(1); i <= 3; i++ {
+		if err := kr.AddEpoch(NewKeyEpoch(i, (&PrivateKey{fr: RandScalar()}).GetPublicKey(), nil, 1, 1)); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	if err := kr.AddEpoch(NewKeyEpoch(2, nil, nil, 1, 1)); err == nil {
+		t.Fatalf("stale epoch accepted.")
+	}
+	ids := kr.Epochs()
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 || kr.Current() != 3 {
+		t.Fatalf("unexpected epochs %v.", ids)
+	}
+	kr.RetireBefore(3)
+	if _, err := kr.Epoch(2); err == nil {
+		t.Fatalf("retired epoch still available.")
+	}
+	ct := &CipherText{epoch: 2}
+	if _, err := kr.DecryptShare([]*CipherText{ct}); err == nil {
+		t.Fatalf("decrypt share with retired epoch.")
+	}
+}
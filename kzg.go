@@ -0,0 +1,340 @@
+package tpke
+
+import (
+	"encoding/binary"
+	"os"
+
+	bls "github.com/kilic/bls12-381"
+	"github.com/txhsl/tpke/polynomial"
+)
+
+// KZGSetup holds powers of tau, tau^i*G1 for i<=degree and tau^i*G2 for a prefix of them
+type KZGSetup struct {
+	g1 []*bls.PointG1
+	g2 []*bls.PointG2
+}
+
+// GenerateKZGSetup samples tau locally, which only suits tests and local networks, since whoever knows tau can forge openings
+func GenerateKZGSetup(degree int) *KZGSetup {
+	tau := RandScalar()
+	g1 := make([]*bls.PointG1, degree+1)
+	g2 := make([]*bls.PointG2, degree+1)
+	power := bls.NewFr().One()
+	for i := range g1 {
+		g1[i] = g1FixedBase().mul(power)
+		g2[i] = g2FixedBase().mul(power)
+		power.Mul(power, tau)
+	}
+	return &KZGSetup{
+		g1: g1,
+		g2: g2,
+	}
+}
+
+// Degree is the highest degree of committed polynomials, a dealing of threshold t needs t-1
+func (s *KZGSetup) Degree() int {
+	return len(s.g1) - 1
+}
+
+// Degree bound of threshold-1 shifts commitments by D-t+1, which needs tau^(D-t+1)*G2
+func (s *KZGSetup) boundShift(threshold int) (int, error) {
+	shift := s.Degree() - threshold + 1
+	if threshold < 1 || shift < 0 || shift >= len(s.g2) {
+		return 0, NewKZGDegreeError()
+	}
+	return shift, nil
+}
+
+// ToBytes encodes the amount of G1 and G2 powers in 4 bytes each, followed by compressed powers
+func (s *KZGSetup) ToBytes() []byte {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	out := make([]byte, 8, 8+len(s.g1)*fpByteSize+len(s.g2)*2*fpByteSize)
+	binary.BigEndian.PutUint32(out[:4], uint32(len(s.g1)))
+	binary.BigEndian.PutUint32(out[4:8], uint32(len(s.g2)))
+	for _, p := range s.g1 {
+		out = append(out, g1.ToCompressed(p)...)
+	}
+	for _, p := range s.g2 {
+		out = append(out, g2.ToCompressed(p)...)
+	}
+	return out
+}
+
+// BytesToKZGSetup checks that all powers are in the subgroups and share the same non-zero tau.
+// Ceremonies usually publish fewer G2 powers, which only bound degrees close to the setup degree.
+func BytesToKZGSetup(b []byte) (*KZGSetup, error) {
+	if len(b) < 8 {
+		return nil, NewKZGSetupError()
+	}
+	n1 := uint64(binary.BigEndian.Uint32(b[:4]))
+	n2 := uint64(binary.BigEndian.Uint32(b[4:8]))
+	if n1 < 2 || n2 < 2 || n2 > n1 || uint64(len(b)) != 8+n1*uint64(fpByteSize)+n2*uint64(2*fpByteSize) {
+		return nil, NewKZGSetupError()
+	}
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	offset := 8
+	powers := make([]*bls.PointG1, n1)
+	for i := range powers {
+		p, err := g1.FromCompressed(b[offset : offset+fpByteSize])
+		if err != nil {
+			return nil, NewKZGSetupError()
+		}
+		powers[i] = p
+		offset += fpByteSize
+	}
+	powers2 := make([]*bls.PointG2, n2)
+	for i := range powers2 {
+		p, err := g2.FromCompressed(b[offset : offset+2*fpByteSize])
+		if err != nil {
+			return nil, NewKZGSetupError()
+		}
+		powers2[i] = p
+		offset += 2 * fpByteSize
+	}
+	// Tau of zero commits every polynomial to its constant term
+	if !g1.Equal(powers[0], &bls.G1One) || !g2.Equal(powers2[0], &bls.G2One) || g1.IsZero(powers[1]) {
+		return nil, NewKZGSetupError()
+	}
+	// e(sum(wi*tau^(i+1)*G1),G2)==e(sum(wi*tau^i*G1),tau*G2) with random wi
+	weights := randBatchScalars(len(powers) - 1)
+	if !pairingCheck(multiExpG1(powers[1:], weights), &bls.G2One, multiExpG1(powers[:len(powers)-1], weights), powers2[1]) {
+		return nil, NewKZGSetupError()
+	}
+	// e(tau*G1,sum(wi*tau^i*G2))==e(G1,sum(wi*tau^(i+1)*G2)) with random wi
+	weights = randBatchScalars(len(powers2) - 1)
+	if !pairingCheck(powers[1], multiExpG2(powers2[:len(powers2)-1], weights), &bls.G1One, multiExpG2(powers2[1:], weights)) {
+		return nil, NewKZGSetupError()
+	}
+	return &KZGSetup{
+		g1: powers,
+		g2: powers2,
+	}, nil
+}
+
+func LoadKZGSetup(path string) (*KZGSetup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return BytesToKZGSetup(data)
+}
+
+func (s *KZGSetup) Save(path string) error {
+	return os.WriteFile(path, s.ToBytes(), 0o644)
+}
+
+// C=sum(ai*tau^i*G1)
+func (s *KZGSetup) commit(p *Poly) (*bls.PointG1, error) {
+	if len(p.coeff) > len(s.g1) {
+		return nil, NewKZGDegreeError()
+	}
+	return multiExpG1(s.g1[:len(p.coeff)], p.coeff), nil
+}
+
+// Opening at x is y=f(x) and a commitment to q(x)=(f(x)-y)/(x-x0)
+func (s *KZGSetup) open(p *Poly, x *bls.Fr) (*bls.Fr, *bls.PointG1, error) {
+	if len(p.coeff) > len(s.g1) {
+		return nil, nil, NewKZGDegreeError()
+	}
+	f := polynomial.New(p.coeff)
+	y := f.Evaluate(x)
+	q, _, _ := f.Sub(polynomial.Constant(y)).Div(polynomial.Linear(x))
+	coeff := q.Coefficients()
+	return y, multiExpG1(s.g1[:len(coeff)], coeff), nil
+}
+
+// e(C-Y,G2)==e(proof,tau*G2-x*G2), the value is a point so that openings in the exponent are checked the same
+func (s *KZGSetup) verifyOpening(c *bls.PointG1, x *bls.Fr, y *bls.PointG1, proof *bls.PointG1) bool {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	lhs := g1.Sub(g1.New(), c, y)
+	rhs := g2.Sub(g2.New(), s.g2[1], g2FixedBase().mul(x))
+	return pairingCheck(lhs, &bls.G2One, proof, rhs)
+}
+
+// Openings of different commitments at the same x are folded with random weights into one check
+func (s *KZGSetup) verifyOpenings(cs []*bls.PointG1, x *bls.Fr, ys []*bls.Fr, proofs []*bls.PointG1) bool {
+	if len(cs) == 0 || len(cs) != len(ys) || len(cs) != len(proofs) {
+		return false
+	}
+	weights := randBatchScalars(len(cs))
+	y := bls.NewFr()
+	wy := bls.NewFr()
+	for i := range ys {
+		wy.Mul(weights[i], ys[i])
+		y.Add(y, wy)
+	}
+	return s.verifyOpening(multiExpG1(cs, weights), x, g1FixedBase().mul(y), multiExpG1(proofs, weights))
+}
+
+// KZGDealing replaces a PVSS in constant size. It carries a commitment C to f, A0=a0*G1, A0'=a0*G2,
+// an opening of C at 0 to A0, and C'=tau^(D-t+1)*C which bounds the degree of f below the threshold t.
+// Shares and their openings are sent to each participant privately.
+type KZGDealing struct {
+	commitment *bls.PointG1
+	pub        *bls.PointG1
+	pub2       *bls.PointG2
+	proof      *bls.PointG1
+	bound      *bls.PointG1
+}
+
+// KZGShare is f(i) of participant i, with an opening of the dealing commitment at i
+type KZGShare struct {
+	value *bls.Fr
+	proof *bls.PointG1
+}
+
+func GenerateKZGDealing(setup *KZGSetup, size int, secret *Secret) (*KZGDealing, []*KZGShare, error) {
+	commitment, err := setup.commit(secret.poly)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, proof, err := setup.open(secret.poly, bls.NewFr().Zero())
+	if err != nil {
+		return nil, nil, err
+	}
+	shift, err := setup.boundShift(len(secret.poly.coeff))
+	if err != nil {
+		return nil, nil, err
+	}
+	shares := make([]*KZGShare, size)
+	for i := 0; i < size; i++ {
+		// Start from 1
+		value, proof, err := setup.open(secret.poly, frFromInt(i+1))
+		if err != nil {
+			return nil, nil, err
+		}
+		shares[i] = &KZGShare{
+			value: value,
+			proof: proof,
+		}
+	}
+	return &KZGDealing{
+		commitment: commitment,
+		pub:        g1FixedBase().mul(secret.poly.coeff[0]),
+		pub2:       g2FixedBase().mul(secret.poly.coeff[0]),
+		proof:      proof,
+		bound:      multiExpG1(setup.g1[shift:shift+len(secret.poly.coeff)], secret.poly.coeff),
+	}, shares, nil
+}
+
+// Verify checks e(A0,G2)==e(G1,A0'), the opening at 0, and e(C,tau^(D-t+1)*G2)==e(C',G2).
+// A polynomial of degree t or more would need C' beyond the powers of the setup.
+func (d *KZGDealing) Verify(setup *KZGSetup, threshold int) bool {
+	shift, err := setup.boundShift(threshold)
+	if err != nil || !pairingCheck(d.commitment, setup.g2[shift], d.bound, &bls.G2One) {
+		return false
+	}
+	if !pairingCheck(d.pub, &bls.G2One, &bls.G1One, d.pub2) {
+		return false
+	}
+	return setup.verifyOpening(d.commitment, bls.NewFr().Zero(), d.pub, d.proof)
+}
+
+// VerifyRenovate checks that a reshared dealing keeps a0
+func (d *KZGDealing) VerifyRenovate(op *KZGDealing) bool {
+	return bls.NewG1().Equal(d.pub, op.pub)
+}
+
+func (d *KZGDealing) ToBytes() []byte {
+	g1 := bls.NewG1()
+	out := make([]byte, 0, 6*fpByteSize)
+	out = append(out, g1.ToCompressed(d.commitment)...)
+	out = append(out, g1.ToCompressed(d.pub)...)
+	out = append(out, bls.NewG2().ToCompressed(d.pub2)...)
+	out = append(out, g1.ToCompressed(d.proof)...)
+	return append(out, g1.ToCompressed(d.bound)...)
+}
+
+func BytesToKZGDealing(b []byte) (*KZGDealing, error) {
+	if len(b) != 6*fpByteSize {
+		return nil, NewKZGEncodingError()
+	}
+	g1 := bls.NewG1()
+	points := make([]*bls.PointG1, 0, 4)
+	for _, offset := range []int{0, fpByteSize, 4 * fpByteSize, 5 * fpByteSize} {
+		p, err := g1.FromCompressed(b[offset : offset+fpByteSize])
+		if err != nil {
+			return nil, NewKZGEncodingError()
+		}
+		points = append(points, p)
+	}
+	g2 := bls.NewG2()
+	pub2, err := g2.FromCompressed(b[2*fpByteSize : 4*fpByteSize])
+	if err != nil {
+		return nil, NewKZGEncodingError()
+	}
+	return &KZGDealing{
+		commitment: points[0],
+		pub:        points[1],
+		pub2:       pub2,
+		proof:      points[2],
+		bound:      points[3],
+	}, nil
+}
+
+func (s *KZGShare) ToBytes() []byte {
+	out := make([]byte, frByteSize+fpByteSize)
+	copy(out[:frByteSize], s.value.ToBytes())
+	copy(out[frByteSize:], bls.NewG1().ToCompressed(s.proof))
+	return out
+}
+
+func BytesToKZGShare(b []byte) (*KZGShare, error) {
+	if len(b) != frByteSize+fpByteSize {
+		return nil, NewKZGEncodingError()
+	}
+	value, err := bytesToFr(b[:frByteSize])
+	if err != nil {
+		return nil, NewKZGEncodingError()
+	}
+	g1 := bls.NewG1()
+	proof, err := g1.FromCompressed(b[frByteSize:])
+	if err != nil {
+		return nil, NewKZGEncodingError()
+	}
+	return &KZGShare{
+		value: value,
+		proof: proof,
+	}, nil
+}
+
+// VerifyShare checks the opening of a single share of participant index
+func (d *KZGDealing) VerifyShare(setup *KZGSetup, index int, share *KZGShare) bool {
+	return setup.verifyOpening(d.commitment, frFromInt(index), g1FixedBase().mul(share.value), share.proof)
+}
+
+// VerifyKZGShares checks shares of participant index from all dealings with one pairing check, after the dealings pass Verify
+func VerifyKZGShares(setup *KZGSetup, dealings []*KZGDealing, index int, shares []*KZGShare) bool {
+	if len(dealings) != len(shares) {
+		return false
+	}
+	cs := make([]*bls.PointG1, len(dealings))
+	ys := make([]*bls.Fr, len(shares))
+	proofs := make([]*bls.PointG1, len(shares))
+	for i := range dealings {
+		cs[i] = dealings[i].commitment
+		ys[i] = shares[i].value
+		proofs[i] = shares[i].proof
+	}
+	return setup.verifyOpenings(cs, frFromInt(index), ys, proofs)
+}
+
+// VerifyKZGVerificationKey checks a key sk*G1 published by participant index, where proof is the sum of openings it received.
+// Openings add up to an opening of sum(C) at index, which is checked in the exponent. The G2 form must match if present.
+func VerifyKZGVerificationKey(setup *KZGSetup, dealings []*KZGDealing, index int, vk *PublicKey, proof *bls.PointG1) bool {
+	if len(dealings) == 0 {
+		return false
+	}
+	g1 := bls.NewG1()
+	c := g1.Zero()
+	for _, d := range dealings {
+		g1.Add(c, c, d.commitment)
+	}
+	if !setup.verifyOpening(c, frFromInt(index), vk.pg1, proof) {
+		return false
+	}
+	return vk.pg2 == nil || pairingCheck(vk.pg1, &bls.G2One, &bls.G1One, vk.pg2)
+}
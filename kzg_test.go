@@ -0,0 +1,213 @@
+package tpke
+
+import (
+	"path/filepath"
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestKZGSetup(t *testing.T) {
+	setup := GenerateKZGSetup(8)
+	path := filepath.Join(t.TempDir(), "setup.bin")
+	if err := setup.Save(path); err != nil {
+		t.Fatalf(err.Error())
+	}
+	loaded, err := LoadKZGSetup(path)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if loaded.Degree() != 8 || len(loaded.g2) != 9 {
+		t.Fatalf("unexpected degree %d", loaded.Degree())
+	}
+
+	// Powers of another tau are rejected
+	b := setup.ToBytes()
+	copy(b[8+3*fpByteSize:8+4*fpByteSize], bls.NewG1().ToCompressed(RandPG1()))
+	if _, err := BytesToKZGSetup(b); err == nil {
+		t.Fatalf("inconsistent setup accepted")
+	}
+	b = setup.ToBytes()
+	copy(b[len(b)-2*fpByteSize:], bls.NewG2().ToCompressed(g2FixedBase().mul(RandScalar())))
+	if _, err := BytesToKZGSetup(b); err == nil {
+		t.Fatalf("inconsistent setup accepted")
+	}
+	if _, err := BytesToKZGSetup(setup.ToBytes()[:100]); err == nil {
+		t.Fatalf("truncated setup accepted")
+	}
+
+	// Tau of zero
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	zero := &KZGSetup{
+		g1: []*bls.PointG1{g1.One(), g1.Zero(), g1.Zero()},
+		g2: []*bls.PointG2{g2.One(), g2.Zero(), g2.Zero()},
+	}
+	if _, err := BytesToKZGSetup(zero.ToBytes()); err == nil {
+		t.Fatalf("setup of zero tau accepted")
+	}
+}
+
+func TestKZGOpening(t *testing.T) {
+	setup := GenerateKZGSetup(5)
+	poly := randomPoly(6)
+	c, err := setup.commit(poly)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	x := RandScalar()
+	y, proof, err := setup.open(poly, x)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !y.Equal(poly.evaluate(*x)) {
+		t.Fatalf("unexpected value")
+	}
+	if !setup.verifyOpening(c, x, g1FixedBase().mul(y), proof) {
+		t.Fatalf("valid opening rejected")
+	}
+	y.Add(y, bls.NewFr().One())
+	if setup.verifyOpening(c, x, g1FixedBase().mul(y), proof) {
+		t.Fatalf("invalid opening accepted")
+	}
+	if _, err := setup.commit(randomPoly(7)); err == nil {
+		t.Fatalf("polynomial beyond setup accepted")
+	}
+}
+
+func TestKZGDealing(t *testing.T) {
+	size := 7
+	threshold := 5
+	// Setup beyond the threshold, where the degree bound matters
+	setup := GenerateKZGSetup(threshold + 3)
+	dealing, shares, err := GenerateKZGDealing(setup, size, RandomSecret(threshold))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	// Dealing has a constant size
+	b := dealing.ToBytes()
+	if len(b) != 6*fpByteSize {
+		t.Fatalf("unexpected dealing size %d", len(b))
+	}
+	dealing, err = BytesToKZGDealing(b)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !dealing.Verify(setup, threshold) {
+		t.Fatalf("valid dealing rejected")
+	}
+	for i := 0; i < size; i++ {
+		share, err := BytesToKZGShare(shares[i].ToBytes())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !dealing.VerifyShare(setup, i+1, share) {
+			t.Fatalf("valid share rejected")
+		}
+	}
+	if dealing.VerifyShare(setup, 2, shares[0]) {
+		t.Fatalf("share of another participant accepted")
+	}
+
+	// Dealing with a wrong A0
+	g1 := bls.NewG1()
+	pub := dealing.pub
+	dealing.pub = g1.Add(g1.New(), pub, &bls.G1One)
+	if dealing.Verify(setup, threshold) {
+		t.Fatalf("invalid dealing accepted")
+	}
+	dealing.pub = pub
+
+	// Dealer of a polynomial of degree t, whose shares open correctly but fail the degree bound
+	cheating, shares, err := GenerateKZGDealing(setup, size, RandomSecret(threshold+1))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !cheating.VerifyShare(setup, 1, shares[0]) || !cheating.Verify(setup, threshold+1) {
+		t.Fatalf("valid dealing rejected")
+	}
+	if cheating.Verify(setup, threshold) {
+		t.Fatalf("dealing beyond the threshold accepted")
+	}
+	cheating.bound = g1.MulScalar(g1.New(), cheating.commitment, RandScalar())
+	if cheating.Verify(setup, threshold) {
+		t.Fatalf("dealing with a forged bound accepted")
+	}
+}
+
+func TestKZGDKG(t *testing.T) {
+	size := 7
+	threshold := 5
+	if _, err := NewDKGWithKZG(size, threshold, MinPubKeySize, GenerateKZGSetup(threshold-2)); err == nil {
+		t.Fatalf("small setup accepted")
+	}
+	setup := GenerateKZGSetup(threshold + 3)
+	short := &KZGSetup{
+		g1: setup.g1,
+		g2: setup.g2[:2],
+	}
+	if _, err := NewDKGWithKZG(size, threshold, MinPubKeySize, short); err == nil {
+		t.Fatalf("setup without the G2 power of the degree bound accepted")
+	}
+	dkg, err := NewDKGWithKZG(size, threshold, MinSigSize, setup)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+	vks := dkg.PublishVerificationKeys()
+	proofs := dkg.PublishVerificationKeyProofs()
+	for i := 1; i <= size; i++ {
+		if !VerifyKZGVerificationKey(dkg.setup, dkg.PublishDealings(), i, vks[i], proofs[i]) {
+			t.Fatalf("valid verification key rejected")
+		}
+	}
+	if VerifyKZGVerificationKey(dkg.setup, dkg.PublishDealings(), 1, vks[2], proofs[2]) {
+		t.Fatalf("verification key of another participant accepted")
+	}
+
+	// Encrypt and decrypt
+	msg := []*bls.PointG1{RandPG1()}
+	cipherTexts := Encrypt(msg, pubkey)
+	shares := decryptShare(cipherTexts, prvkeys)
+	results, err := Decrypt(cipherTexts, shares, pubkey, threshold, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bls.NewG1().Equal(msg[0], results[0]) {
+		t.Fatalf("decryption failed.")
+	}
+
+	// Sign with verification keys
+	sigShares := make(map[int]*SignatureShare)
+	for i := 1; i <= size; i++ {
+		sigShares[i] = prvkeys[i].SignShareWithMode([]byte("pizza"), MinSigSize)
+	}
+	sig, _, err := AggregateAndVerifySigWithMode(pubkey, vks, []byte("pizza"), threshold, sigShares, dkg.GetScaler(), MinSigSize)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !pubkey.VerifySig([]byte("pizza"), sig) {
+		t.Fatalf("invalid signature")
+	}
+
+	// Reshare keeps the global key
+	dkg.Reshare()
+	if err := dkg.VerifyReshare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bls.NewG1().Equal(pubkey.pg1, dkg.PublishGlobalPublicKey().pg1) {
+		t.Fatalf("global key changed by reshare")
+	}
+
+	// Tampered share is caught
+	dkg.Prepare()
+	dkg.messageBox[3][1] = dkg.messageBox[3][2]
+	if err := dkg.VerifyPrepare(); err == nil {
+		t.Fatalf("invalid share accepted")
+	}
+}
@@ -0,0 +1,100 @@
+package tpke
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestEncryptMessage(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+
+	// Encrypt a 32-byte key and an empty message
+	msgs := [][]byte{make([]byte, 32), {}}
+	rand.Read(msgs[0])
+	cts := make([]*MessageCipherText, len(msgs))
+	keys := make([]*CipherText, len(msgs))
+	for i, msg := range msgs {
+		ct, err := pubkey.EncryptMessage(msg)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		cts[i], err = BytesToMessageCipherText(ct.ToBytes())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		keys[i] = cts[i].Key()
+	}
+
+	// Decrypt
+	results, err := DecryptMessages(cts, decryptShare(keys, prvkeys), pubkey, threshold, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i := range msgs {
+		if !bytes.Equal(msgs[i], results[i]) {
+			t.Fatalf("decryption failed.")
+		}
+	}
+
+	// Wrong key and tampered message
+	if _, err := cts[0].Open(RandPG1()); err == nil {
+		t.Fatalf("opened with a wrong key.")
+	}
+	b := cts[0].ToBytes()
+	b[len(b)-1] ^= 1
+	tampered, err := BytesToMessageCipherText(b)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err := DecryptMessages([]*MessageCipherText{tampered}, decryptShare(keys[:1], prvkeys), pubkey, threshold, dkg.GetScaler()); err == nil {
+		t.Fatalf("tampered message opened.")
+	}
+}
+
+func TestCipherTextSubgroup(t *testing.T) {
+	dkg := NewDKG(4, 3)
+	dkg.Prepare()
+	pubkey := dkg.PublishGlobalPublicKey()
+	ct := pubkey.Encrypt(RandPG1())
+
+	// Find a point on the curve, which is outside the subgroup with an overwhelming chance
+	g1 := bls.NewG1()
+	var outside *bls.PointG1
+	for x := int64(1); outside == nil; x++ {
+		rhs := new(big.Int).Exp(big.NewInt(x), big.NewInt(3), fpModulus)
+		rhs.Add(rhs, big.NewInt(4))
+		y := new(big.Int).ModSqrt(rhs.Mod(rhs, fpModulus), fpModulus)
+		if y == nil {
+			continue
+		}
+		in := make([]byte, 2*fpByteSize)
+		big.NewInt(x).FillBytes(in[:fpByteSize])
+		y.FillBytes(in[fpByteSize:])
+		p, err := g1.FromBytes(in)
+		if err == nil && !g1.InCorrectSubgroup(p) {
+			outside = p
+		}
+	}
+	b := ct.ToBytes()
+	copy(b[:fpByteSize], g1.ToCompressed(outside))
+	if _, err := BytesToCipherText(b); err == nil {
+		t.Fatalf("point outside the subgroup accepted.")
+	}
+	b = pubkey.EncryptBatch(RandPG1(), 1).ToBytes()
+	copy(b[batchByteSize:batchByteSize+fpByteSize], g1.ToCompressed(outside))
+	if _, err := BytesToBatchCipherText(b); err == nil {
+		t.Fatalf("point outside the subgroup accepted.")
+	}
+}
@@ -0,0 +1,33 @@
+package tpke
+
+import (
+	bls "github.com/kilic/bls12-381"
+)
+
+// multiExpG1 computes sum(si*Pi) by Pippenger's bucket method.
+// Points are copied first, since the batch affine conversion writes to its input.
+func multiExpG1(points []*bls.PointG1, scalars []*bls.Fr) *bls.PointG1 {
+	g1 := bls.NewG1()
+	if len(points) == 0 {
+		return g1.Zero()
+	}
+	ps := make([]*bls.PointG1, len(points))
+	for i := range points {
+		ps[i] = g1.New().Set(points[i])
+	}
+	result, _ := g1.MultiExp(g1.New(), ps, scalars)
+	return result
+}
+
+func multiExpG2(points []*bls.PointG2, scalars []*bls.Fr) *bls.PointG2 {
+	g2 := bls.NewG2()
+	if len(points) == 0 {
+		return g2.Zero()
+	}
+	ps := make([]*bls.PointG2, len(points))
+	for i := range points {
+		ps[i] = g2.New().Set(points[i])
+	}
+	result, _ := g2.MultiExp(g2.New(), ps, scalars)
+	return result
+}
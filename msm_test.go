@@ -0,0 +1,32 @@
+package tpke
+
+import (
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestMultiExp(t *testing.T) {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	for _, n := range []int{0, 1, 7, 150} {
+		p1s := make([]*bls.PointG1, n)
+		p2s := make([]*bls.PointG2, n)
+		scalars := make([]*bls.Fr, n)
+		e1 := g1.Zero()
+		e2 := g2.Zero()
+		for i := 0; i < n; i++ {
+			scalars[i] = RandScalar()
+			p1s[i] = RandPG1()
+			p2s[i] = g2.MulScalar(g2.New(), &bls.G2One, RandScalar())
+			g1.Add(e1, e1, g1.MulScalar(g1.New(), p1s[i], scalars[i]))
+			g2.Add(e2, e2, g2.MulScalar(g2.New(), p2s[i], scalars[i]))
+		}
+		if !g1.Equal(multiExpG1(p1s, scalars), e1) {
+			t.Fatalf("inconsistent result in G1 with %d points", n)
+		}
+		if !g2.Equal(multiExpG2(p2s, scalars), e2) {
+			t.Fatalf("inconsistent result in G2 with %d points", n)
+		}
+	}
+}
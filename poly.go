@@ -63,12 +63,10 @@ func (p *Poly) degree() int {
 }
 
 func (p *Poly) commitment() *Commitment {
-	g1 := bls.NewG1()
-	ci := g1.New()
+	table := g1FixedBase()
 	coeff := make([]*bls.PointG1, len(p.coeff))
 	for i := range coeff {
-		g1.MulScalar(ci, &bls.G1One, p.coeff[i])
-		coeff[i] = g1.New().Set(ci)
+		coeff[i] = table.mul(p.coeff[i])
 	}
 	return &Commitment{
 		coeff: coeff,
@@ -95,20 +93,17 @@ func (c *Commitment) degree() int {
 }
 
 func (c *Commitment) evaluate(x bls.Fr) *bls.PointG1 {
-	g1 := bls.NewG1()
-	if len(c.coeff) == 0 {
-		return g1.Zero()
-	}
-	i := len(c.coeff) - 1
-	result := g1.New().Set(c.coeff[i])
-	for i >= 0 {
-		if i != len(c.coeff)-1 {
-			g1.MulScalar(result, result, &x)
-			g1.Add(result, result, c.coeff[i])
+	// F(x)=sum(Ai*x^i) in one multi-scalar multiplication
+	powers := make([]*bls.Fr, len(c.coeff))
+	for i := range powers {
+		if i == 0 {
+			powers[i] = bls.NewFr().One()
+			continue
 		}
-		i--
+		powers[i] = bls.NewFr()
+		powers[i].Mul(powers[i-1], &x)
 	}
-	return result
+	return multiExpG1(c.coeff, powers)
 }
 
 func (c *Commitment) AddAssign(op *Commitment) {
@@ -0,0 +1,322 @@
+// Package polynomial implements polynomials over the scalar field Fr of BLS12-381,
+// for protocols that share, reshare and repair secrets.
+package polynomial
+
+import (
+	"errors"
+	"math/big"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+var (
+	ErrLengthMismatch = errors.New("polynomial: length mismatch")
+	ErrDuplicatePoint = errors.New("polynomial: duplicate point")
+	ErrDivisionByZero = errors.New("polynomial: division by zero")
+)
+
+// Poly keeps coefficients from the constant term on, without trailing zeros, so the zero polynomial has none.
+// Methods never modify their receiver or arguments.
+type Poly struct {
+	coeff []*bls.Fr
+}
+
+// New copies coefficients, a0 first
+func New(coeff []*bls.Fr) *Poly {
+	p := &Poly{
+		coeff: make([]*bls.Fr, len(coeff)),
+	}
+	for i := range coeff {
+		p.coeff[i] = bls.NewFr().Set(coeff[i])
+	}
+	return p.trim()
+}
+
+func Zero() *Poly {
+	return &Poly{}
+}
+
+// Constant is the polynomial c
+func Constant(c *bls.Fr) *Poly {
+	return New([]*bls.Fr{c})
+}
+
+// Linear is the polynomial x-x0
+func Linear(x0 *bls.Fr) *Poly {
+	neg := bls.NewFr()
+	neg.Neg(x0)
+	return New([]*bls.Fr{neg, bls.NewFr().One()})
+}
+
+func (p *Poly) trim() *Poly {
+	n := len(p.coeff)
+	for n > 0 && p.coeff[n-1].IsZero() {
+		n--
+	}
+	p.coeff = p.coeff[:n]
+	return p
+}
+
+// Coefficients returns a copy, a0 first
+func (p *Poly) Coefficients() []*bls.Fr {
+	coeff := make([]*bls.Fr, len(p.coeff))
+	for i := range coeff {
+		coeff[i] = bls.NewFr().Set(p.coeff[i])
+	}
+	return coeff
+}
+
+// Degree of the zero polynomial is -1
+func (p *Poly) Degree() int {
+	return len(p.coeff) - 1
+}
+
+func (p *Poly) IsZero() bool {
+	return len(p.coeff) == 0
+}
+
+func (p *Poly) Equal(q *Poly) bool {
+	if len(p.coeff) != len(q.coeff) {
+		return false
+	}
+	for i := range p.coeff {
+		if !p.coeff[i].Equal(q.coeff[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate with Horner's rule
+func (p *Poly) Evaluate(x *bls.Fr) *bls.Fr {
+	result := bls.NewFr().Zero()
+	for i := len(p.coeff) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, p.coeff[i])
+	}
+	return result
+}
+
+func (p *Poly) Add(q *Poly) *Poly {
+	n := len(p.coeff)
+	if len(q.coeff) > n {
+		n = len(q.coeff)
+	}
+	coeff := make([]*bls.Fr, n)
+	for i := range coeff {
+		coeff[i] = bls.NewFr().Zero()
+		if i < len(p.coeff) {
+			coeff[i].Add(coeff[i], p.coeff[i])
+		}
+		if i < len(q.coeff) {
+			coeff[i].Add(coeff[i], q.coeff[i])
+		}
+	}
+	return (&Poly{coeff: coeff}).trim()
+}
+
+func (p *Poly) Sub(q *Poly) *Poly {
+	return p.Add(q.Scale(minusOne()))
+}
+
+func (p *Poly) Scale(s *bls.Fr) *Poly {
+	coeff := make([]*bls.Fr, len(p.coeff))
+	for i := range coeff {
+		coeff[i] = bls.NewFr()
+		coeff[i].Mul(p.coeff[i], s)
+	}
+	return (&Poly{coeff: coeff}).trim()
+}
+
+// Mul is the schoolbook product, which is fast enough for degrees of a committee
+func (p *Poly) Mul(q *Poly) *Poly {
+	if p.IsZero() || q.IsZero() {
+		return Zero()
+	}
+	coeff := make([]*bls.Fr, len(p.coeff)+len(q.coeff)-1)
+	for i := range coeff {
+		coeff[i] = bls.NewFr().Zero()
+	}
+	t := bls.NewFr()
+	for i := range p.coeff {
+		for j := range q.coeff {
+			t.Mul(p.coeff[i], q.coeff[j])
+			coeff[i+j].Add(coeff[i+j], t)
+		}
+	}
+	return (&Poly{coeff: coeff}).trim()
+}
+
+// Div is the long division p=q*d+r with deg(r)<deg(d)
+func (p *Poly) Div(d *Poly) (*Poly, *Poly, error) {
+	if d.IsZero() {
+		return nil, nil, ErrDivisionByZero
+	}
+	if len(p.coeff) < len(d.coeff) {
+		return Zero(), New(p.coeff), nil
+	}
+	lead := bls.NewFr()
+	lead.Inverse(d.coeff[len(d.coeff)-1])
+	remainder := p.Coefficients()
+	quotient := make([]*bls.Fr, len(p.coeff)-len(d.coeff)+1)
+	t := bls.NewFr()
+	for i := len(quotient) - 1; i >= 0; i-- {
+		quotient[i] = bls.NewFr()
+		quotient[i].Mul(remainder[i+len(d.coeff)-1], lead)
+		for j := range d.coeff {
+			t.Mul(quotient[i], d.coeff[j])
+			remainder[i+j].Sub(remainder[i+j], t)
+		}
+	}
+	return (&Poly{coeff: quotient}).trim(), (&Poly{coeff: remainder[:len(d.coeff)-1]}).trim(), nil
+}
+
+func (p *Poly) Derivative() *Poly {
+	if len(p.coeff) < 2 {
+		return Zero()
+	}
+	coeff := make([]*bls.Fr, len(p.coeff)-1)
+	for i := range coeff {
+		coeff[i] = bls.NewFr()
+		coeff[i].Mul(p.coeff[i+1], fromInt(i+1))
+	}
+	return (&Poly{coeff: coeff}).trim()
+}
+
+// Vanishing is prod(x-xi), which is zero exactly at xs
+func Vanishing(xs []*bls.Fr) *Poly {
+	return buildTree(xs).poly
+}
+
+// EvaluateMany reduces p modulo the subproduct tree of xs, so that values at the leaves are remainders of degree 0
+func (p *Poly) EvaluateMany(xs []*bls.Fr) []*bls.Fr {
+	ys := make([]*bls.Fr, len(xs))
+	if len(xs) == 0 {
+		return ys
+	}
+	p.evaluateTree(buildTree(xs), xs, ys)
+	return ys
+}
+
+func (p *Poly) evaluateTree(node *tree, xs []*bls.Fr, ys []*bls.Fr) {
+	if node.left == nil {
+		for i := range xs {
+			ys[i] = p.Evaluate(xs[i])
+		}
+		return
+	}
+	mid := len(xs) / 2
+	_, left, _ := p.Div(node.left.poly)
+	_, right, _ := p.Div(node.right.poly)
+	left.evaluateTree(node.left, xs[:mid], ys[:mid])
+	right.evaluateTree(node.right, xs[mid:], ys[mid:])
+}
+
+// Interpolate returns the unique polynomial of degree below len(xs) through all points,
+// p(x)=sum(yi*V(x)/((x-xi)*V'(xi))) with V the vanishing polynomial of xs
+func Interpolate(xs []*bls.Fr, ys []*bls.Fr) (*Poly, error) {
+	if len(xs) != len(ys) {
+		return nil, ErrLengthMismatch
+	}
+	if len(xs) == 0 {
+		return Zero(), nil
+	}
+	weights, err := barycentricWeights(xs)
+	if err != nil {
+		return nil, err
+	}
+	v := Vanishing(xs)
+	result := Zero()
+	for i := range xs {
+		q, _, _ := v.Div(Linear(xs[i]))
+		s := bls.NewFr()
+		s.Mul(ys[i], weights[i])
+		result = result.Add(q.Scale(s))
+	}
+	return result, nil
+}
+
+// LagrangeCoefficients li at x, such that p(x)=sum(li*p(xi)) for p of degree below len(xs)
+func LagrangeCoefficients(xs []*bls.Fr, x *bls.Fr) ([]*bls.Fr, error) {
+	weights, err := barycentricWeights(xs)
+	if err != nil {
+		return nil, err
+	}
+	// li=V(x)*wi/(x-xi), or 1 at xi if x is one of xs
+	coeff := make([]*bls.Fr, len(xs))
+	for i := range xs {
+		if xs[i].Equal(x) {
+			for j := range coeff {
+				coeff[j] = bls.NewFr().Zero()
+			}
+			coeff[i].One()
+			return coeff, nil
+		}
+	}
+	vx := bls.NewFr().One()
+	t := bls.NewFr()
+	for i := range xs {
+		t.Sub(x, xs[i])
+		vx.Mul(vx, t)
+	}
+	for i := range xs {
+		coeff[i] = bls.NewFr()
+		t.Sub(x, xs[i])
+		coeff[i].Inverse(t)
+		coeff[i].Mul(coeff[i], weights[i])
+		coeff[i].Mul(coeff[i], vx)
+	}
+	return coeff, nil
+}
+
+// wi=1/V'(xi)=1/prod(xi-xj)
+func barycentricWeights(xs []*bls.Fr) ([]*bls.Fr, error) {
+	weights := Vanishing(xs).Derivative().EvaluateMany(xs)
+	for i := range weights {
+		if weights[i].IsZero() {
+			return nil, ErrDuplicatePoint
+		}
+		weights[i].Inverse(weights[i])
+	}
+	return weights, nil
+}
+
+// Subproduct tree, every node keeps the vanishing polynomial of its points
+type tree struct {
+	poly  *Poly
+	left  *tree
+	right *tree
+}
+
+// Small nodes are leaves, where Horner's rule beats further division
+var leafSize = 8
+
+func buildTree(xs []*bls.Fr) *tree {
+	if len(xs) <= leafSize {
+		poly := Constant(bls.NewFr().One())
+		for i := range xs {
+			poly = poly.Mul(Linear(xs[i]))
+		}
+		return &tree{
+			poly: poly,
+		}
+	}
+	mid := len(xs) / 2
+	left := buildTree(xs[:mid])
+	right := buildTree(xs[mid:])
+	return &tree{
+		poly:  left.poly.Mul(right.poly),
+		left:  left,
+		right: right,
+	}
+}
+
+func minusOne() *bls.Fr {
+	e := bls.NewFr().One()
+	e.Neg(e)
+	return e
+}
+
+func fromInt(x int) *bls.Fr {
+	return bls.NewFr().FromBytes(big.NewInt(int64(x)).Bytes())
+}
@@ -0,0 +1,131 @@
+package polynomial
+
+import (
+	"crypto/rand"
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func randPoly(n int) *Poly {
+	coeff := make([]*bls.Fr, n)
+	for i := range coeff {
+		coeff[i], _ = bls.NewFr().Rand(rand.Reader)
+	}
+	return New(coeff)
+}
+
+func randPoints(n int) []*bls.Fr {
+	xs := make([]*bls.Fr, n)
+	for i := range xs {
+		xs[i], _ = bls.NewFr().Rand(rand.Reader)
+	}
+	return xs
+}
+
+func TestArithmetic(t *testing.T) {
+	p := randPoly(7)
+	q := randPoly(4)
+	x := randPoints(1)[0]
+
+	// (p*q)(x)==p(x)*q(x), (p+q)(x)==p(x)+q(x)
+	expected := bls.NewFr()
+	expected.Mul(p.Evaluate(x), q.Evaluate(x))
+	if !p.Mul(q).Evaluate(x).Equal(expected) {
+		t.Fatalf("unexpected product")
+	}
+	expected.Add(p.Evaluate(x), q.Evaluate(x))
+	if !p.Add(q).Evaluate(x).Equal(expected) {
+		t.Fatalf("unexpected sum")
+	}
+	if !p.Sub(p).IsZero() || p.Sub(p).Degree() != -1 {
+		t.Fatalf("unexpected difference")
+	}
+
+	// p==quotient*q+remainder
+	quotient, remainder, err := p.Div(q)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if quotient.Degree() != 3 || remainder.Degree() >= q.Degree() {
+		t.Fatalf("unexpected degrees")
+	}
+	if !quotient.Mul(q).Add(remainder).Equal(p) {
+		t.Fatalf("unexpected division")
+	}
+	if _, _, err := p.Div(Zero()); err != ErrDivisionByZero {
+		t.Fatalf("division by zero")
+	}
+
+	// (x^3)'==3x^2
+	one := bls.NewFr().One()
+	zero := bls.NewFr().Zero()
+	cube := New([]*bls.Fr{zero, zero, zero, one})
+	if !cube.Derivative().Equal(New([]*bls.Fr{zero, zero, fromInt(3)})) {
+		t.Fatalf("unexpected derivative")
+	}
+}
+
+func TestVanishing(t *testing.T) {
+	xs := randPoints(20)
+	v := Vanishing(xs)
+	if v.Degree() != len(xs) {
+		t.Fatalf("unexpected degree")
+	}
+	for _, y := range v.EvaluateMany(xs) {
+		if !y.IsZero() {
+			t.Fatalf("vanishing polynomial is not zero")
+		}
+	}
+	if v.Evaluate(randPoints(1)[0]).IsZero() {
+		t.Fatalf("vanishing polynomial is zero elsewhere")
+	}
+}
+
+func TestEvaluateMany(t *testing.T) {
+	p := randPoly(50)
+	xs := randPoints(37)
+	ys := p.EvaluateMany(xs)
+	for i := range xs {
+		if !ys[i].Equal(p.Evaluate(xs[i])) {
+			t.Fatalf("unexpected value")
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	p := randPoly(30)
+	xs := randPoints(30)
+	q, err := Interpolate(xs, p.EvaluateMany(xs))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !q.Equal(p) {
+		t.Fatalf("unexpected interpolation")
+	}
+
+	// Lagrange coefficients at 0 and at one of the points
+	for _, x := range []*bls.Fr{bls.NewFr().Zero(), xs[3]} {
+		coeff, err := LagrangeCoefficients(xs, x)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		result := bls.NewFr().Zero()
+		for i := range xs {
+			y := p.Evaluate(xs[i])
+			y.Mul(y, coeff[i])
+			result.Add(result, y)
+		}
+		if !result.Equal(p.Evaluate(x)) {
+			t.Fatalf("unexpected lagrange coefficients")
+		}
+	}
+
+	if _, err := Interpolate(xs, xs[1:]); err != ErrLengthMismatch {
+		t.Fatalf("mismatched lengths accepted")
+	}
+	xs[1] = xs[0]
+	if _, err := Interpolate(xs, xs); err != ErrDuplicatePoint {
+		t.Fatalf("duplicate points accepted")
+	}
+}
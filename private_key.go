@@ -20,10 +20,9 @@ func NewPrivateKey(secretShares []*bls.Fr) *PrivateKey {
 }
 
 func (sk *PrivateKey) GetPublicKey() *PublicKey {
-	g1 := bls.NewG1()
-	pg1 := g1.New()
 	return &PublicKey{
-		pg1: g1.MulScalar(pg1, &bls.G1One, sk.fr),
+		pg1: g1FixedBase().mul(sk.fr),
+		pg2: g2FixedBase().mul(sk.fr),
 	}
 }
 
@@ -38,12 +37,41 @@ func (sk *PrivateKey) DecryptShare(ct *CipherText) *DecryptionShare {
 }
 
 func (sk *PrivateKey) SignShare(msg []byte) *SignatureShare {
+	return sk.SignShareWithMode(msg, MinPubKeySize)
+}
+
+func (sk *PrivateKey) SignShareWithMode(msg []byte, mode SignatureMode) *SignatureShare {
+	// Default context has no augmentation, which never fails
+	share, _ := sk.SignShareWithContext(defaultSigningContext(mode), msg)
+	return share
+}
+
+// In augmentation scheme, threshold shares must be signed in a context bound to the global public key, e.g. ctx.WithKey(pk),
+// since AggregateAndVerifySigWithContext only combines those. An unbound context prefixes the key of sk itself, which suits
+// standalone keys only. In min-sig mode, the bound key must have its G2 form.
+func (sk *PrivateKey) SignShareWithContext(ctx *SigningContext, msg []byte) (*SignatureShare, error) {
+	var signer *PublicKey
+	if ctx.scheme == AugScheme && ctx.key == nil {
+		signer = sk.GetPublicKey()
+	}
+	if ctx.mode == MinSigSize {
+		// S=H(msg)*sk in G1
+		g1Hash, err := ctx.hashToG1(signer, msg)
+		if err != nil {
+			return nil, err
+		}
+		g1 := bls.NewG1()
+		sig := g1.New()
+		g1.MulScalar(sig, g1Hash, sk.fr)
+		return &SignatureShare{
+			pg1: sig,
+		}, nil
+	}
 	// S=H(msg)*sk
 	g2 := bls.NewG2()
-	g2Hash, _ := g2.HashToCurve(msg, Domain)
 	sig := g2.New()
-	g2.MulScalar(sig, g2Hash, sk.fr)
+	g2.MulScalar(sig, ctx.hashToG2(signer, msg), sk.fr)
 	return &SignatureShare{
 		pg2: sig,
-	}
+	}, nil
 }
@@ -2,12 +2,15 @@ package tpke
 
 import (
 	"math/big"
+	"sort"
 
 	bls "github.com/kilic/bls12-381"
 )
 
 type PublicKey struct {
-	pg1 *bls.PointG1
+	pg1   *bls.PointG1
+	pg2   *bls.PointG2 // Optional G2 form, required by compact ciphertexts and min-sig signatures
+	table *fixedBaseG1 // Optional table of pg1, built by Precompute
 }
 
 func NewGlobalPublicKey(cs []*Commitment, scaler int) *PublicKey {
@@ -28,15 +31,11 @@ func (pk *PublicKey) Encrypt(msg *bls.PointG1) *CipherText {
 
 	// C=M+rpk, R1=rG1, R2=rG2
 	g1 := bls.NewG1()
-	g2 := bls.NewG2()
-	bigR1 := g1.New()
-	bigR2 := g2.New()
-	g1.MulScalar(bigR1, &bls.G1One, r)
-	g2.MulScalar(bigR2, &bls.G2One, r)
+	bigR1 := g1FixedBase().mul(r)
+	bigR2 := g2FixedBase().mul(r)
 
-	rpk := g1.New()
+	rpk := pk.mul(r)
 	cMsg := g1.New()
-	g1.MulScalar(rpk, pk.pg1, r)
 	g1.Add(cMsg, msg, rpk)
 
 	return &CipherText{
@@ -46,16 +45,128 @@ func (pk *PublicKey) Encrypt(msg *bls.PointG1) *CipherText {
 	}
 }
 
+// EncryptCompact makes a ciphertext of epoch 0, whose proof is bound to pk and checked by VerifyCipherText
+func (pk *PublicKey) EncryptCompact(msg *bls.PointG1) *CipherText {
+	return pk.encryptCompact(msg, 0)
+}
+
+func (pk *PublicKey) encryptCompact(msg *bls.PointG1, epoch uint64) *CipherText {
+	r := RandScalar()
+	k := RandScalar()
+
+	// C=M+rpk, R1=rG1, A=kG1, c=H(pk,epoch,C,R1,A), z=k+cr
+	g1 := bls.NewG1()
+	bigR1 := g1FixedBase().mul(r)
+	bigA := g1FixedBase().mul(k)
+
+	rpk := pk.mul(r)
+	cMsg := g1.New()
+	g1.Add(cMsg, msg, rpk)
+
+	c := encryptionChallenge(pk, epoch, cMsg, bigR1, bigA)
+	z := bls.NewFr()
+	z.Mul(c, r)
+	z.Add(z, k)
+
+	return &CipherText{
+		cMsg: cMsg,
+		bigR: bigR1,
+		proof: &encryptionProof{
+			c: c,
+			z: z,
+		},
+		epoch: epoch,
+	}
+}
+
 func (pk *PublicKey) VerifySigShare(msg []byte, sig *SignatureShare) bool {
 	return pk.VerifySig(msg, (*Signature)(sig))
 }
 
 func (pk *PublicKey) VerifySig(msg []byte, sig *Signature) bool {
-	g2 := bls.NewG2()
-	g2Hash, _ := g2.HashToCurve(msg, Domain)
+	return pk.VerifySigWithContext(defaultSigningContext(sig.Mode()), msg, sig)
+}
 
-	pairing := bls.NewEngine()
-	e1 := pairing.AddPair(pk.pg1, g2Hash).Result()
-	e2 := pairing.AddPair(&bls.G1One, sig.pg2).Result()
-	return e1.Equal(e2)
+func (pk *PublicKey) VerifySigShareWithContext(ctx *SigningContext, msg []byte, sig *SignatureShare) bool {
+	return pk.VerifySigWithContext(ctx, msg, (*Signature)(sig))
+}
+
+func (pk *PublicKey) VerifySigWithContext(ctx *SigningContext, msg []byte, sig *Signature) bool {
+	if sig.Mode() != ctx.mode {
+		return false
+	}
+	if sig.Mode() == MinSigSize {
+		if pk.pg2 == nil {
+			return false
+		}
+		g1Hash, err := ctx.hashToG1(pk, msg)
+		if err != nil {
+			return false
+		}
+		return pairingCheck(g1Hash, pk.pg2, sig.pg1, &bls.G2One)
+	}
+	g2Hash := ctx.hashToG2(pk, msg)
+	return pairingCheck(pk.pg1, g2Hash, &bls.G1One, sig.pg2)
+}
+
+// VerifySigBatch checks signatures on many messages with a few pairings, and reports the invalid ones by an IndexedError
+func (pk *PublicKey) VerifySigBatch(msgs [][]byte, sigs []*Signature) error {
+	if len(msgs) != len(sigs) {
+		return NewSigBatchSizeError()
+	}
+	// Signatures of each mode are checked in its default context
+	invalid := make([]int, 0)
+	for _, mode := range []SignatureMode{MinPubKeySize, MinSigSize} {
+		indices := make([]int, 0, len(sigs))
+		for i := 0; i < len(sigs); i++ {
+			if sigs[i] != nil && sigs[i].Mode() == mode {
+				indices = append(indices, i)
+			}
+		}
+		if len(indices) == 0 {
+			continue
+		}
+		batch, err := newSigBatch(defaultSigningContext(mode), pk, msgs, sigs, indices)
+		if err != nil {
+			return err
+		}
+		invalid = append(invalid, batch.search(indices)...)
+	}
+	for i := 0; i < len(sigs); i++ {
+		if sigs[i] == nil {
+			invalid = append(invalid, i)
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Ints(invalid)
+		return NewSigBatchError(invalid)
+	}
+	return nil
+}
+
+func (pk *PublicKey) VerifySigBatchWithContext(ctx *SigningContext, msgs [][]byte, sigs []*Signature) error {
+	if len(msgs) != len(sigs) {
+		return NewSigBatchSizeError()
+	}
+	indices := make([]int, 0, len(sigs))
+	invalid := make([]int, 0)
+	for i := 0; i < len(sigs); i++ {
+		if sigs[i] != nil && sigs[i].Mode() == ctx.mode {
+			indices = append(indices, i)
+		} else {
+			invalid = append(invalid, i)
+		}
+	}
+	if len(indices) > 0 {
+		batch, err := newSigBatch(ctx, pk, msgs, sigs, indices)
+		if err != nil {
+			return err
+		}
+		invalid = append(invalid, batch.search(indices)...)
+	}
+	if len(invalid) > 0 {
+		sort.Ints(invalid)
+		return NewSigBatchError(invalid)
+	}
+	return nil
 }
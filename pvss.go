@@ -1,61 +1,88 @@
 package tpke
 
 import (
-	"math/big"
-
 	bls "github.com/kilic/bls12-381"
 )
 
 type PVSS struct {
 	commitment *Commitment
+	pub2       *bls.PointG2 // A0 in G2, a0*G2
 	r1         *bls.PointG1
 	r2         *bls.PointG2
 	bigf       []*bls.PointG1
+	bigf2      []*bls.PointG2 // Only in min-sig mode, F(i) in G2
 }
 
+// GenerateSharedSecrets commits to the secret once, and every public share F(i)=f(i)*G1 is a fixed-base multiplication
 func GenerateSharedSecrets(r *bls.Fr, size int, secret *Secret) (*PVSS, []*bls.Fr) {
-	g1 := bls.NewG1()
-	g2 := bls.NewG2()
-	r1 := g1.New()
-	r2 := g2.New()
-	g1.MulScalar(r1, &bls.G1One, r)
-	g2.MulScalar(r2, &bls.G2One, r)
+	r1 := g1FixedBase().mul(r)
+	r2 := g2FixedBase().mul(r)
 	f := make([]*bls.Fr, size)
 	bigf := make([]*bls.PointG1, size)
 	for i := 0; i < size; i++ {
-		// Start from 1
-		fr := bls.NewFr().FromBytes(big.NewInt(int64(i + 1)).Bytes())
-		// Compute secret share f(i)
-		f[i] = secret.poly.evaluate(*fr)
-		// Compute public share F(i)=f(i)*G1
-		bigf[i] = secret.poly.commitment().evaluate(*fr)
+		// Start from 1, compute secret share f(i)
+		f[i] = secret.poly.evaluate(*frFromInt(i + 1))
+		bigf[i] = g1FixedBase().mul(f[i])
 	}
+	pub2 := g2FixedBase().mul(secret.poly.coeff[0])
 	return &PVSS{
 		commitment: secret.Commitment(),
+		pub2:       pub2,
 		r1:         r1,
 		r2:         r2,
 		bigf:       bigf,
 	}, f
 }
 
+// CommitSharesG2 publishes F'(i)=f(i)*G2, so that verification keys exist in G2 for min-sig signatures
+func (pvss *PVSS) CommitSharesG2(f []*bls.Fr) {
+	bigf2 := make([]*bls.PointG2, len(f))
+	for i := 0; i < len(f); i++ {
+		bigf2[i] = g2FixedBase().mul(f[i])
+	}
+	pvss.bigf2 = bigf2
+}
+
 func (pvss *PVSS) VerifyCommitment() bool {
 	g1 := bls.NewG1()
 	// Verify e(R1,G2)==e(G1,R2)
-	pairing := bls.NewEngine()
-	pairing.AddPair(pvss.r1, &bls.G2One)
-	e1 := pairing.Result()
-	pairing.AddPair(&bls.G1One, pvss.r2)
-	e2 := pairing.Result()
-	if !e1.Equal(e2) {
+	if !pairingCheck(pvss.r1, &bls.G2One, &bls.G1One, pvss.r2) {
+		return false
+	}
+	// Verify e(A0,G2)==e(G1,A0')
+	if !pairingCheck(pvss.commitment.coeff[0], &bls.G2One, &bls.G1One, pvss.pub2) {
 		return false
 	}
+	if len(pvss.bigf) == 0 {
+		return true
+	}
+	// Verify F(i)==sum(Aj*i^j) for all i at once with random weights w_i,
+	// sum(w_i*F(i))==sum(sum(w_i*i^j)*Aj)
+	weights := randBatchScalars(len(pvss.bigf))
+	powers := make([]*bls.Fr, len(pvss.commitment.coeff))
+	for j := range powers {
+		powers[j] = bls.NewFr()
+	}
 	for i := range pvss.bigf {
-		fr := bls.NewFr().FromBytes(big.NewInt(int64(i + 1)).Bytes())
-		// Verify F(i)==sum(A_{t-1}*i^(t-1))
-		if !g1.Equal(pvss.bigf[i], pvss.commitment.evaluate(*fr)) {
+		x := frFromInt(i + 1)
+		xj := bls.NewFr().Set(weights[i])
+		for j := range powers {
+			powers[j].Add(powers[j], xj)
+			xj.Mul(xj, x)
+		}
+	}
+	weighted := multiExpG1(pvss.bigf, weights)
+	if !g1.Equal(weighted, multiExpG1(pvss.commitment.coeff, powers)) {
+		return false
+	}
+	if pvss.bigf2 != nil {
+		if len(pvss.bigf2) != len(pvss.bigf) {
+			return false
+		}
+		// Verify e(sum(w_i*F(i)),G2)==e(G1,sum(w_i*F'(i)))
+		if !pairingCheck(weighted, &bls.G2One, &bls.G1One, multiExpG2(pvss.bigf2, weights)) {
 			return false
 		}
-
 	}
 	return true
 }
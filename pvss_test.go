@@ -0,0 +1,49 @@
+package tpke
+
+import (
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestPVSSVerifyCommitment(t *testing.T) {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	size := 200
+	threshold := 134
+	secret := RandomSecret(threshold)
+	pvss, f := GenerateSharedSecrets(RandScalar(), size, secret)
+	if len(f) != size {
+		t.Fatalf("unexpected share amount")
+	}
+	for _, i := range []int{0, 99, size - 1} {
+		x := frFromInt(i + 1)
+		if !g1.Equal(pvss.bigf[i], secret.Commitment().evaluate(*x)) {
+			t.Fatalf("public share mismatches commitment")
+		}
+	}
+	pvss.CommitSharesG2(f)
+	if !pvss.VerifyCommitment() {
+		t.Fatalf("valid pvss rejected")
+	}
+
+	// Tamper with a public share in G1
+	bigf := pvss.bigf[57]
+	pvss.bigf[57] = g1.Add(g1.New(), bigf, &bls.G1One)
+	if pvss.VerifyCommitment() {
+		t.Fatalf("tampered public share accepted")
+	}
+	pvss.bigf[57] = bigf
+
+	// Tamper with a public share in G2
+	bigf2 := pvss.bigf2[131]
+	pvss.bigf2[131] = g2.Add(g2.New(), bigf2, &bls.G2One)
+	if pvss.VerifyCommitment() {
+		t.Fatalf("tampered public share in G2 accepted")
+	}
+	pvss.bigf2[131] = bigf2
+
+	if !pvss.VerifyCommitment() {
+		t.Fatalf("valid pvss rejected")
+	}
+}
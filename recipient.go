@@ -0,0 +1,149 @@
+package tpke
+
+import (
+	bls "github.com/kilic/bls12-381"
+)
+
+// RecipientKey belongs to an end user, who is the only one able to combine hidden shares
+type RecipientKey struct {
+	fr *bls.Fr
+}
+
+type RecipientPublicKey struct {
+	pg1 *bls.PointG1
+	pg2 *bls.PointG2
+}
+
+func NewRecipientKey() *RecipientKey {
+	return &RecipientKey{
+		fr: RandScalar(),
+	}
+}
+
+func (rk *RecipientKey) GetPublicKey() *RecipientPublicKey {
+	return &RecipientPublicKey{
+		pg1: g1FixedBase().mul(rk.fr),
+		pg2: g2FixedBase().mul(rk.fr),
+	}
+}
+
+func (rpk *RecipientPublicKey) ToBytes() []byte {
+	out := make([]byte, 3*fpByteSize)
+	copy(out[:fpByteSize], bls.NewG1().ToCompressed(rpk.pg1))
+	copy(out[fpByteSize:], bls.NewG2().ToCompressed(rpk.pg2))
+	return out
+}
+
+func BytesToRecipientPublicKey(b []byte) (*RecipientPublicKey, error) {
+	if len(b) != 3*fpByteSize {
+		return nil, NewTPKERecipientError()
+	}
+	pg1, err := bls.NewG1().FromCompressed(b[:fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	pg2, err := bls.NewG2().FromCompressed(b[fpByteSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &RecipientPublicKey{
+		pg1: pg1,
+		pg2: pg2,
+	}, nil
+}
+
+func (rpk *RecipientPublicKey) Verify() error {
+	// Recipient sends an inconsistent key, e(X1,G2)!=e(G1,X2)
+	if !pairingCheck(rpk.pg1, &bls.G2One, &bls.G1One, rpk.pg2) {
+		return NewTPKERecipientError()
+	}
+	return nil
+}
+
+// HiddenDecryptionShare is an ElGamal encryption of a decryption share under the recipient key
+type HiddenDecryptionShare struct {
+	bigK *bls.PointG1 // k*G1
+	pg1  *bls.PointG1 // sk*R1+k*X1
+}
+
+func (s *HiddenDecryptionShare) ToBytes() []byte {
+	out := make([]byte, 2*fpByteSize)
+	g1 := bls.NewG1()
+	copy(out[:fpByteSize], g1.ToCompressed(s.bigK))
+	copy(out[fpByteSize:], g1.ToCompressed(s.pg1))
+	return out
+}
+
+func BytesToHiddenDecryptionShare(b []byte) (*HiddenDecryptionShare, error) {
+	if len(b) != 2*fpByteSize {
+		return nil, NewTPKEShareError()
+	}
+	g1 := bls.NewG1()
+	bigK, err := g1.FromCompressed(b[:fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	pg1, err := g1.FromCompressed(b[fpByteSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &HiddenDecryptionShare{
+		bigK: bigK,
+		pg1:  pg1,
+	}, nil
+}
+
+func (sk *PrivateKey) HiddenDecryptShare(ct *CipherText, recipient *RecipientPublicKey) *HiddenDecryptionShare {
+	k := RandScalar()
+
+	// K=k*G1, S=sk*R1+k*X1
+	g1 := bls.NewG1()
+	bigK := g1FixedBase().mul(k)
+	mask := g1.New()
+	g1.MulScalar(mask, recipient.pg1, k)
+	share := sk.DecryptShare(ct)
+	g1.Add(share.pg1, share.pg1, mask)
+
+	return &HiddenDecryptionShare{
+		bigK: bigK,
+		pg1:  share.pg1,
+	}
+}
+
+// VerifyHiddenShare lets anyone check a hidden share against the verification key of its sender, without learning the share
+func VerifyHiddenShare(ct *CipherText, vk *PublicKey, recipient *RecipientPublicKey, share *HiddenDecryptionShare) error {
+	// Shares are checked against R2, which compact ciphertexts do not carry
+	if ct.Format() != StandardFormat {
+		return NewTPKECiphertextError()
+	}
+	// S-x*K=sk*R1, so e(S,G2)*e(-vk,R2)*e(-K,X2)==1
+	pairing := bls.NewEngine()
+	pairing.AddPair(share.pg1, &bls.G2One)
+	pairing.AddPairInv(vk.pg1, ct.commitment).AddPairInv(share.bigK, recipient.pg2)
+	if !pairing.Check() {
+		return NewTPKEShareError()
+	}
+	return nil
+}
+
+// Decrypt removes the masks of hidden shares and combines them like Decrypt
+func (rk *RecipientKey) Decrypt(cts []*CipherText, inputs map[int]([]*HiddenDecryptionShare), pub *PublicKey, threshold int, scaler int) ([]*bls.PointG1, error) {
+	g1 := bls.NewG1()
+	shares := make(map[int]([]*DecryptionShare))
+	for index, v := range inputs {
+		if len(v) != len(cts) {
+			continue
+		}
+		// D=S-x*K
+		s := make([]*DecryptionShare, len(v))
+		for i := 0; i < len(v); i++ {
+			mask := g1.New()
+			g1.MulScalar(mask, v[i].bigK, rk.fr)
+			s[i] = &DecryptionShare{
+				pg1: g1.Sub(g1.New(), v[i].pg1, mask),
+			}
+		}
+		shares[index] = s
+	}
+	return Decrypt(cts, shares, pub, threshold, scaler)
+}
@@ -0,0 +1,63 @@
+package tpke
+
+import (
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestDesignatedDecryption(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+	vks := dkg.PublishVerificationKeys()
+
+	// Recipient publishes its key
+	rk := NewRecipientKey()
+	recipient, err := BytesToRecipientPublicKey(rk.GetPublicKey().ToBytes())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := recipient.Verify(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Encrypt
+	msg := RandPG1()
+	cts := []*CipherText{pubkey.Encrypt(msg)}
+
+	// Generate hidden shares
+	shares := make(map[int]([]*HiddenDecryptionShare))
+	for i := 1; i <= size; i++ {
+		shares[i] = []*HiddenDecryptionShare{prvkeys[i].HiddenDecryptShare(cts[0], recipient)}
+		if err := VerifyHiddenShare(cts[0], vks[i], recipient, shares[i][0]); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	// Put a wrong share
+	shares[2][0] = prvkeys[3].HiddenDecryptShare(cts[0], recipient)
+	if err := VerifyHiddenShare(cts[0], vks[2], recipient, shares[2][0]); err == nil {
+		t.Fatalf("wrong share accepted.")
+	}
+
+	// Others can not decrypt
+	if _, err := NewRecipientKey().Decrypt(cts, shares, pubkey, threshold, dkg.GetScaler()); err == nil {
+		t.Fatalf("decrypted without recipient key.")
+	}
+
+	// Recipient decrypts
+	results, err := rk.Decrypt(cts, shares, pubkey, threshold, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bls.NewG1().Equal(msg, results[0]) {
+		t.Fatalf("decryption failed.")
+	}
+}
@@ -0,0 +1,165 @@
+package tpke
+
+import (
+	"sort"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+// ReEncryptionShare converts a piece of ciphertext from the old key to the new key without revealing rpk
+type ReEncryptionShare struct {
+	bigA *bls.PointG1 // rho*G1
+	bigB *bls.PointG2 // rho*G2
+	bigS *bls.PointG1 // rho*pk'-sk*R1
+}
+
+func (s *ReEncryptionShare) ToBytes() []byte {
+	out := make([]byte, 4*fpByteSize)
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	copy(out[:fpByteSize], g1.ToCompressed(s.bigA))
+	copy(out[fpByteSize:3*fpByteSize], g2.ToCompressed(s.bigB))
+	copy(out[3*fpByteSize:], g1.ToCompressed(s.bigS))
+	return out
+}
+
+func BytesToReEncryptionShare(b []byte) (*ReEncryptionShare, error) {
+	if len(b) != 4*fpByteSize {
+		return nil, NewTPKEShareError()
+	}
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	bigA, err := g1.FromCompressed(b[:fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	bigB, err := g2.FromCompressed(b[fpByteSize : 3*fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	bigS, err := g1.FromCompressed(b[3*fpByteSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &ReEncryptionShare{
+		bigA: bigA,
+		bigB: bigB,
+		bigS: bigS,
+	}, nil
+}
+
+func (sk *PrivateKey) ReEncryptShare(ct *CipherText, newPub *PublicKey) *ReEncryptionShare {
+	rho := RandScalar()
+
+	// A=rho*G1, B=rho*G2, S=rho*pk'-sk*R1
+	g1 := bls.NewG1()
+	bigA := g1FixedBase().mul(rho)
+	bigB := g2FixedBase().mul(rho)
+	bigS := newPub.mul(rho)
+	skR := g1.New()
+	g1.MulScalar(skR, ct.bigR, sk.fr)
+	g1.Sub(bigS, bigS, skR)
+
+	return &ReEncryptionShare{
+		bigA: bigA,
+		bigB: bigB,
+		bigS: bigS,
+	}
+}
+
+// VerifyReEncryptionShare checks the share against the verification key of its sender
+func VerifyReEncryptionShare(ct *CipherText, newPub *PublicKey, vk *PublicKey, share *ReEncryptionShare) error {
+	// Shares are checked against R2, which compact ciphertexts do not carry
+	if ct.Format() != StandardFormat {
+		return NewTPKECiphertextError()
+	}
+	// e(A,G2)==e(G1,B)
+	if !pairingCheck(share.bigA, &bls.G2One, &bls.G1One, share.bigB) {
+		return NewTPKEShareError()
+	}
+	// e(S,G2)*e(-pk',B)*e(vk,R2)==1
+	pairing := bls.NewEngine()
+	pairing.AddPair(share.bigS, &bls.G2One)
+	pairing.AddPairInv(newPub.pg1, share.bigB).AddPair(vk.pg1, ct.commitment)
+	if !pairing.Check() {
+		return NewTPKEShareError()
+	}
+	return nil
+}
+
+// ReEncrypt combines valid shares of the old committee into a ciphertext of the same message under the new key.
+// The result can be checked against the old ciphertext by VerifyReEncryption.
+func ReEncrypt(ct *CipherText, newPub *PublicKey, newEpoch uint64, vks map[int]*PublicKey, inputs map[int]*ReEncryptionShare, threshold int, scaler int) (*CipherText, error) {
+	if ct.Format() != StandardFormat {
+		return nil, NewTPKECiphertextError()
+	}
+	if err := ct.Verify(); err != nil {
+		return nil, err
+	}
+	// Drop invalid shares
+	indices := make([]int, 0, len(inputs))
+	for index, v := range inputs {
+		vk, ok := vks[index]
+		if !ok {
+			continue
+		}
+		if VerifyReEncryptionShare(ct, newPub, vk, v) != nil {
+			continue
+		}
+		indices = append(indices, index)
+	}
+	if len(indices) < threshold {
+		return nil, NewTPKENotEnoughShareError()
+	}
+	sort.Ints(indices)
+	indices = indices[:threshold]
+
+	// C'=C+scaler*sum(li*Si), R1'=scaler*sum(li*Ai), R2'=scaler*sum(li*Bi)
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	bigS := g1.Zero()
+	bigA := g1.Zero()
+	bigB := g2.Zero()
+	coeff, err := lagrangeCoefficients(indices)
+	if err != nil {
+		return nil, err
+	}
+	for i, index := range indices {
+		share := inputs[index]
+		l := bls.NewFr().Set(coeff[i])
+		l.Mul(l, frFromInt(scaler))
+		g1.Add(bigS, bigS, g1.MulScalar(g1.New(), share.bigS, l))
+		g1.Add(bigA, bigA, g1.MulScalar(g1.New(), share.bigA, l))
+		g2.Add(bigB, bigB, g2.MulScalar(g2.New(), share.bigB, l))
+	}
+
+	return &CipherText{
+		cMsg:       g1.Add(bigS, bigS, ct.cMsg),
+		bigR:       bigA,
+		commitment: bigB,
+		epoch:      newEpoch,
+	}, nil
+}
+
+// VerifyReEncryption proves that both ciphertexts hide the same message, with no need to decrypt any of them
+func VerifyReEncryption(oldCt *CipherText, newCt *CipherText, oldPub *PublicKey, newPub *PublicKey) error {
+	if oldCt.Format() != StandardFormat || newCt.Format() != StandardFormat {
+		return NewTPKECiphertextError()
+	}
+	if err := oldCt.Verify(); err != nil {
+		return err
+	}
+	if err := newCt.Verify(); err != nil {
+		return err
+	}
+	// C'-C=r'pk'-rpk, so e(C'-C,G2)*e(-pk',R2')*e(pk,R2)==1
+	g1 := bls.NewG1()
+	delta := g1.Sub(g1.New(), newCt.cMsg, oldCt.cMsg)
+	pairing := bls.NewEngine()
+	pairing.AddPair(delta, &bls.G2One)
+	pairing.AddPairInv(newPub.pg1, newCt.commitment).AddPair(oldPub.pg1, oldCt.commitment)
+	if !pairing.Check() {
+		return NewTPKEReEncryptionError()
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package tpke
+
+import (
+	"testing"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+func TestReEncryption(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	oldPub := dkg.PublishGlobalPublicKey()
+	oldPrvs := dkg.GetPrivateKeysFromPrepare()
+	vks := dkg.PublishVerificationKeys()
+	oldScaler := dkg.GetScaler()
+
+	// Encrypt with old key
+	msg := RandPG1()
+	oldCt := oldPub.Encrypt(msg)
+
+	// Move to a new key
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	newPub := dkg.PublishGlobalPublicKey()
+	newPrvs := dkg.GetPrivateKeysFromPrepare()
+
+	// Old committee converts the ciphertext
+	shares := make(map[int]*ReEncryptionShare)
+	for i := 1; i <= size; i++ {
+		shares[i] = oldPrvs[i].ReEncryptShare(oldCt, newPub)
+	}
+	share, err := BytesToReEncryptionShare(shares[1].ToBytes())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	shares[1] = share
+
+	// Put a wrong share
+	shares[2] = newPrvs[2].ReEncryptShare(oldCt, newPub)
+	if err := VerifyReEncryptionShare(oldCt, newPub, vks[2], shares[2]); err == nil {
+		t.Fatalf("wrong share accepted.")
+	}
+
+	newCt, err := ReEncrypt(oldCt, newPub, dkg.GetEpoch(), vks, shares, threshold, oldScaler)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := VerifyReEncryption(oldCt, newCt, oldPub, newPub); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := VerifyReEncryption(oldCt, newPub.Encrypt(RandPG1()), oldPub, newPub); err == nil {
+		t.Fatalf("unrelated ciphertext accepted.")
+	}
+	// Malformed source, whose R1 does not match R2
+	malformed := &CipherText{
+		cMsg:       oldCt.cMsg,
+		bigR:       RandPG1(),
+		commitment: oldCt.commitment,
+		epoch:      oldCt.epoch,
+	}
+	if err := VerifyReEncryption(malformed, newCt, oldPub, newPub); err == nil {
+		t.Fatalf("re-encryption of a malformed ciphertext accepted.")
+	}
+
+	// New committee decrypts
+	cts := []*CipherText{newCt}
+	results, err := Decrypt(cts, decryptShare(cts, newPrvs), newPub, threshold, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bls.NewG1().Equal(msg, results[0]) {
+		t.Fatalf("decryption failed.")
+	}
+}
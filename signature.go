@@ -1,15 +1,23 @@
 package tpke
 
 import (
-	"math"
-	"math/big"
+	"sort"
 
 	bls "github.com/kilic/bls12-381"
 )
 
 var Domain = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+var DomainG1 = []byte("BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_")
+
+type SignatureMode int
+
+const (
+	MinPubKeySize SignatureMode = iota // Public keys in G1, signatures in G2
+	MinSigSize                         // Public keys in G2, signatures in G1
+)
 
 type Signature struct {
+	pg1 *bls.PointG1 // Only in min-sig mode
 	pg2 *bls.PointG2
 }
 
@@ -19,16 +27,42 @@ func NewSignature(pg2 *bls.PointG2) *Signature {
 	}
 }
 
+func (s *Signature) Mode() SignatureMode {
+	if s.pg1 != nil {
+		return MinSigSize
+	}
+	return MinPubKeySize
+}
+
 func (s *Signature) Equals(sig *Signature) bool {
+	if s.Mode() != sig.Mode() {
+		return false
+	}
+	if s.Mode() == MinSigSize {
+		return bls.NewG1().Equal(s.pg1, sig.pg1)
+	}
 	g2 := bls.NewG2()
 	return g2.Equal(s.pg2, sig.pg2)
 }
 
 func (s *Signature) ToBytes() []byte {
+	if s.Mode() == MinSigSize {
+		return bls.NewG1().ToCompressed(s.pg1)
+	}
 	return bls.NewG2().ToCompressed(s.pg2)
 }
 
+// Signature of 48 bytes is decoded in min-sig mode
 func BytesToSig(b []byte) (*Signature, error) {
+	if len(b) == fpByteSize {
+		pg1, err := bls.NewG1().FromCompressed(b)
+		if err != nil {
+			return nil, err
+		}
+		return &Signature{
+			pg1: pg1,
+		}, nil
+	}
 	pg2, err := bls.NewG2().FromCompressed(b)
 	if err != nil {
 		return nil, err
@@ -39,80 +73,290 @@ func BytesToSig(b []byte) (*Signature, error) {
 }
 
 type SignatureShare struct {
+	pg1 *bls.PointG1 // Only in min-sig mode
 	pg2 *bls.PointG2
 }
 
+func (s *SignatureShare) Mode() SignatureMode {
+	if s.pg1 != nil {
+		return MinSigSize
+	}
+	return MinPubKeySize
+}
+
 func (s *SignatureShare) ToBytes() []byte {
+	if s.Mode() == MinSigSize {
+		return bls.NewG1().ToCompressed(s.pg1)
+	}
 	return bls.NewG2().ToCompressed(s.pg2)
 }
 
 func BytesToSigShare(b []byte) (*SignatureShare, error) {
-	pg2, err := bls.NewG2().FromCompressed(b)
+	sig, err := BytesToSig(b)
 	if err != nil {
 		return nil, err
 	}
-	return &SignatureShare{
-		pg2: pg2,
-	}, nil
+	return (*SignatureShare)(sig), nil
 }
 
 func AggregateAndVerifySig(pk *PublicKey, msg []byte, threshold int, inputs map[int]*SignatureShare, scaler int) (*Signature, error) {
-	if len(inputs) < threshold {
-		return nil, NewSigNotEnoughShareError()
+	sig, _, err := AggregateAndVerifySigWithKeys(pk, nil, msg, threshold, inputs, scaler)
+	return sig, err
+}
+
+// AggregateAndVerifySigWithKeys checks every share by its verification key, and returns the indices of dropped shares beside the signature
+func AggregateAndVerifySigWithKeys(pk *PublicKey, vks map[int]*PublicKey, msg []byte, threshold int, inputs map[int]*SignatureShare, scaler int) (*Signature, []int, error) {
+	return AggregateAndVerifySigWithMode(pk, vks, msg, threshold, inputs, scaler, MinPubKeySize)
+}
+
+func AggregateAndVerifySigWithMode(pk *PublicKey, vks map[int]*PublicKey, msg []byte, threshold int, inputs map[int]*SignatureShare, scaler int, mode SignatureMode) (*Signature, []int, error) {
+	return AggregateAndVerifySigWithContext(defaultSigningContext(mode), pk, vks, msg, threshold, inputs, scaler)
+}
+
+// With verification keys, every share is checked by its own key, and invalid shares are dropped and returned.
+// An error is only returned without a valid signature, which is an IndexedError of invalid shares if not enough are left.
+// Without verification keys, valid shares are searched for within maxDecodingAttempts sets, which may fail with many corrupted ones.
+// In augmentation scheme, the context must be bound to the global public key, as the one shares are signed in.
+func AggregateAndVerifySigWithContext(ctx *SigningContext, pk *PublicKey, vks map[int]*PublicKey, msg []byte, threshold int, inputs map[int]*SignatureShare, scaler int) (*Signature, []int, error) {
+	if ctx.scheme == AugScheme && (ctx.key == nil || !bls.NewG1().Equal(ctx.key.pg1, pk.pg1)) {
+		return nil, nil, NewSigContextUnboundError()
 	}
+	var g1Hash *bls.PointG1
+	var g2Hash *bls.PointG2
+	if ctx.mode == MinSigSize {
+		var err error
+		if g1Hash, err = ctx.hashToG1(pk, msg); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		g2Hash = ctx.hashToG2(pk, msg)
+	}
+	return aggregateAndVerifyHashed(pk, vks, g1Hash, g2Hash, ctx.mode, threshold, inputs, scaler)
+}
 
-	matrix := make([][]int, len(inputs))           // size=len(inputs)*threshold, including all rows
-	shares := make([]*SignatureShare, len(inputs)) // size=len(inputs), including all shares
+// Shares are signatures on the hashed point, which is in G1 in min-sig mode and in G2 otherwise
+func aggregateAndVerifyHashed(pk *PublicKey, vks map[int]*PublicKey, g1Hash *bls.PointG1, g2Hash *bls.PointG2, mode SignatureMode, threshold int, inputs map[int]*SignatureShare, scaler int) (*Signature, []int, error) {
+	if vks == nil {
+		// Shares of another mode never combine
+		shares := make(map[int]*SignatureShare)
+		for index, v := range inputs {
+			if v.Mode() == mode {
+				shares[index] = v
+			}
+		}
+		sig, err := aggregateAndVerify(threshold, shares, scaler, func(sig *Signature) bool {
+			return verifySigShareOnce(pk, g1Hash, g2Hash, (*SignatureShare)(sig))
+		})
+		return sig, nil, err
+	}
+	if len(inputs) < threshold {
+		return nil, nil, NewSigNotEnoughShareError()
+	}
 
-	// Be aware of a random order of sig shares
-	i := 0
+	// Drop invalid shares
+	valid := make([]int, 0, len(inputs))
+	invalid := make([]int, 0)
 	for index, v := range inputs {
-		row := make([]int, threshold)
-		for j := 0; j < threshold; j++ {
-			row[j] = int(math.Pow(float64(index), float64(j)))
+		vk, ok := vks[index]
+		if ok && v.Mode() == mode && verifySigShareOnce(vk, g1Hash, g2Hash, v) {
+			valid = append(valid, index)
+		} else {
+			invalid = append(invalid, index)
+		}
+	}
+	sort.Ints(valid)
+	sort.Ints(invalid)
+	if len(valid) < threshold {
+		return nil, invalid, NewSigInvalidShareError(invalid)
+	}
+	sig, err := interpolateShares(valid[:threshold], inputs, scaler)
+	if err != nil {
+		return nil, invalid, err
+	}
+	return sig, invalid, nil
+}
+
+func verifySigShareOnce(vk *PublicKey, g1Hash *bls.PointG1, g2Hash *bls.PointG2, share *SignatureShare) bool {
+	if share.Mode() == MinSigSize {
+		if vk.pg2 == nil {
+			return false
+		}
+		// e(H(msg),vk')==e(S,G2)
+		return pairingCheck(g1Hash, vk.pg2, share.pg1, &bls.G2One)
+	}
+	// e(vk,H(msg))==e(G1,S)
+	return pairingCheck(vk.pg1, g2Hash, &bls.G1One, share.pg2)
+}
+
+// sigBatch hashes every distinct message once, and keeps the hashes for the search of invalid signatures
+type sigBatch struct {
+	ctx      *SigningContext
+	pk       *PublicKey
+	sigs     []*Signature
+	slots    []int // Index of the distinct message of every signature
+	g1Hashes []*bls.PointG1
+	g2Hashes []*bls.PointG2
+}
+
+func newSigBatch(ctx *SigningContext, pk *PublicKey, msgs [][]byte, sigs []*Signature, indices []int) (*sigBatch, error) {
+	batch := &sigBatch{
+		ctx:   ctx,
+		pk:    pk,
+		sigs:  sigs,
+		slots: make([]int, len(sigs)),
+	}
+	seen := make(map[string]int)
+	for _, index := range indices {
+		slot, ok := seen[string(msgs[index])]
+		if !ok {
+			slot = len(seen)
+			seen[string(msgs[index])] = slot
+			if ctx.mode == MinSigSize {
+				g1Hash, err := ctx.hashToG1(pk, msgs[index])
+				if err != nil {
+					return nil, err
+				}
+				batch.g1Hashes = append(batch.g1Hashes, g1Hash)
+			} else {
+				batch.g2Hashes = append(batch.g2Hashes, ctx.hashToG2(pk, msgs[index]))
+			}
 		}
-		matrix[i] = row
-		shares[i] = v
-		i++
-	}
-
-	// Use different combinations to verify
-	combs := getCombs(len(inputs), threshold)
-	for _, v := range combs {
-		m := make([][]int, threshold)           // size=threshold*threshold, only seleted rows
-		s := make([]*SignatureShare, threshold) // size=threshold, only seleted shares
-		for i := 0; i < len(v); i++ {
-			m[i] = matrix[v[i]]
-			s[i] = shares[v[i]]
+		batch.slots[index] = slot
+	}
+	return batch, nil
+}
+
+func (batch *sigBatch) search(indices []int) []int {
+	if len(indices) == 0 || batch.verifyOnce(indices) {
+		return nil
+	}
+	if len(indices) == 1 {
+		return indices
+	}
+	mid := len(indices) / 2
+	return append(batch.search(indices[:mid]), batch.search(indices[mid:])...)
+}
+
+func (batch *sigBatch) verifyOnce(indices []int) bool {
+	scalars := randBatchScalars(len(indices))
+	// Scalars of a repeated message add up, so that its hash is multiplied once
+	slots := make(map[int]*bls.Fr)
+	order := make([]int, 0, len(indices))
+	for i, index := range indices {
+		slot := batch.slots[index]
+		if d, ok := slots[slot]; ok {
+			d.Add(d, scalars[i])
+			continue
+		}
+		slots[slot] = bls.NewFr().Set(scalars[i])
+		order = append(order, slot)
+	}
+	coeff := make([]*bls.Fr, len(order))
+	for i, slot := range order {
+		coeff[i] = slots[slot]
+	}
+
+	if batch.ctx.mode == MinSigSize {
+		if batch.pk.pg2 == nil {
+			return false
+		}
+		// e(sum(di*Hi),pk')==e(sum(di*Si),G2)
+		sigs := make([]*bls.PointG1, len(indices))
+		for i, index := range indices {
+			sigs[i] = batch.sigs[index].pg1
 		}
-		sig := aggregateShares(m, s, scaler)
-		if pk.VerifySig(msg, sig) {
-			return sig, nil
+		hashes := make([]*bls.PointG1, len(order))
+		for i, slot := range order {
+			hashes[i] = batch.g1Hashes[slot]
 		}
+		return pairingCheck(multiExpG1(hashes, coeff), batch.pk.pg2, multiExpG1(sigs, scalars), &bls.G2One)
+	}
+	// e(pk,sum(di*Hi))==e(G1,sum(di*Si))
+	sigs := make([]*bls.PointG2, len(indices))
+	for i, index := range indices {
+		sigs[i] = batch.sigs[index].pg2
 	}
+	hashes := make([]*bls.PointG2, len(order))
+	for i, slot := range order {
+		hashes[i] = batch.g2Hashes[slot]
+	}
+	return pairingCheck(batch.pk.pg1, multiExpG2(hashes, coeff), &bls.G1One, multiExpG2(sigs, scalars))
+}
 
-	return nil, NewSigAggregationError()
+// Compute S=scaler*sum(li*Si) with lagrange coefficients over Fr
+func interpolateShares(indices []int, inputs map[int]*SignatureShare, scaler int) (*Signature, error) {
+	coeff, err := lagrangeCoefficients(indices)
+	if err != nil {
+		return nil, err
+	}
+	for i := range coeff {
+		coeff[i].Mul(coeff[i], frFromInt(scaler))
+	}
+	if inputs[indices[0]].Mode() == MinSigSize {
+		points := make([]*bls.PointG1, len(indices))
+		for i, index := range indices {
+			points[i] = inputs[index].pg1
+		}
+		return &Signature{
+			pg1: multiExpG1(points, coeff),
+		}, nil
+	}
+	points := make([]*bls.PointG2, len(indices))
+	for i, index := range indices {
+		points[i] = inputs[index].pg2
+	}
+	return NewSignature(multiExpG2(points, coeff)), nil
 }
 
-func aggregateShares(matrix [][]int, shares []*SignatureShare, scaler int) *Signature {
-	// Be aware of the integer overflow when the size and threshold grow big
-	d, coeff := feldman(matrix)
-	d = scaler / d
-	// Compute d1
-	denominator := bls.NewFr().FromBytes(big.NewInt(int64(abs(d))).Bytes())
-	g2 := bls.NewG2()
-	pg2 := g2.Zero()
-	// Add up shares with some factors as d1
-	for i := 0; i < len(shares); i++ {
-		minor := g2.New()
-		g2.MulScalar(minor, shares[i].pg2, bls.NewFr().FromBytes(big.NewInt(int64(abs(coeff[i]))).Bytes()))
-		if coeff[i] < 0 {
-			g2.Neg(minor, minor)
+// Corrupted shares are located by decoding, and any threshold honest shares are searched for beyond that, within maxDecodingAttempts
+func aggregateAndVerify(threshold int, inputs map[int]*SignatureShare, scaler int, verify func(*Signature) bool) (*Signature, error) {
+	if len(inputs) < threshold {
+		return nil, NewSigNotEnoughShareError()
+	}
+
+	// Be aware of a random order of sig shares
+	indices := make([]int, 0, len(inputs))
+	for index := range inputs {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	// Corrupted shares are located first, within the search bound
+	if invalid, err := LocateInvalidSigShares(inputs, threshold); err == nil {
+		valid := make([]int, 0, len(indices))
+		for _, index := range indices {
+			if len(invalid) > 0 && invalid[0] == index {
+				invalid = invalid[1:]
+				continue
+			}
+			valid = append(valid, index)
+		}
+		if len(valid) >= threshold {
+			if sig, err := interpolateShares(valid[:threshold], inputs, scaler); err == nil && verify(sig) {
+				return sig, nil
+			}
 		}
-		g2.Add(pg2, pg2, minor)
 	}
-	// Divide d1 by d
-	g2.MulScalar(pg2, pg2, denominator)
-	// Verify
-	return NewSignature(pg2)
+
+	// Beyond that, threshold honest shares are searched for
+	var sig *Signature
+	_, err := searchShares(len(indices), threshold, func(positions []int) bool {
+		selected := make([]int, len(positions))
+		for i, pos := range positions {
+			selected[i] = indices[pos]
+		}
+		// Shares of another mode never combine
+		for _, index := range selected {
+			if inputs[index].Mode() != inputs[selected[0]].Mode() {
+				return false
+			}
+		}
+		var err error
+		sig, err = interpolateShares(selected, inputs, scaler)
+		return err == nil && verify(sig)
+	})
+	if err != nil {
+		return nil, NewSigSearchError()
+	}
+	return sig, nil
 }
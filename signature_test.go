@@ -1,7 +1,6 @@
 package tpke
 
 import (
-	"math"
 	"math/rand"
 	"testing"
 	"time"
@@ -55,33 +54,30 @@ func TestThresholdSignature(t *testing.T) {
 	}
 
 	// Test consistency
-	matrix := make([][]int, len(shares))           // size=len(shares)*threshold, including all rows
-	inputs := make([]*SignatureShare, len(shares)) // size=len(shares), including all shares
-
-	// Be aware of a random order of decryption shares
-	i := 0
-	for index, v := range shares {
-		row := make([]int, threshold)
-		for j := 0; j < threshold; j++ {
-			row[j] = int(math.Pow(float64(index), float64(j)))
-		}
-		matrix[i] = row
-		inputs[i] = v
-		i++
+	indices := make([]int, 0, len(shares))
+	for index := range shares {
+		indices = append(indices, index)
 	}
 
 	// Use different combinations to aggregate
-	combs := getCombs(len(shares), threshold)
+	comb := make([]int, threshold)
+	for i := range comb {
+		comb[i] = i
+	}
 	sigs := make([]*Signature, 0)
-	for _, v := range combs {
-		m := make([][]int, threshold)           // size=threshold*threshold, only seleted rows
-		s := make([]*SignatureShare, threshold) // size=threshold, only seleted shares
-		for i := 0; i < len(v); i++ {
-			m[i] = matrix[v[i]]
-			s[i] = inputs[v[i]]
+	for {
+		selected := make([]int, threshold)
+		for i := 0; i < len(comb); i++ {
+			selected[i] = indices[comb[i]]
+		}
+		sig, err := interpolateShares(selected, shares, scaler)
+		if err != nil {
+			t.Fatalf(err.Error())
 		}
-		sig := aggregateShares(m, s, scaler)
 		sigs = append(sigs, sig)
+		if !nextComb(comb, len(shares)) {
+			break
+		}
 	}
 
 	s0 := sigs[0]
@@ -91,3 +87,149 @@ func TestThresholdSignature(t *testing.T) {
 		}
 	}
 }
+
+func TestThresholdSignatureWithVerificationKeys(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	sks := dkg.GetPrivateKeysFromPrepare()
+	pk := dkg.PublishGlobalPublicKey()
+	vks := dkg.PublishVerificationKeys()
+	scaler := dkg.GetScaler()
+
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+	shares := make(map[int]*SignatureShare)
+	for i := 1; i <= len(sks); i++ {
+		shares[i] = sks[i].SignShare(msg)
+	}
+	sig, invalid, err := AggregateAndVerifySigWithKeys(pk, vks, msg, threshold, shares, scaler)
+	if err != nil || len(invalid) != 0 {
+		t.Fatalf(err.Error())
+	}
+	if !pk.VerifySig(msg, sig) {
+		t.Fatalf("invalid signature")
+	}
+
+	// Put wrong shares
+	shares[2] = sks[3].SignShare(msg)
+	shares[6] = sks[6].SignShare([]byte("pizza"))
+	sig, invalid, err = AggregateAndVerifySigWithKeys(pk, vks, msg, threshold, shares, scaler)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !pk.VerifySig(msg, sig) {
+		t.Fatalf("invalid signature")
+	}
+	if len(invalid) != 2 || invalid[0] != 2 || invalid[1] != 6 {
+		t.Fatalf("unexpected invalid shares %v", invalid)
+	}
+
+	// Not enough valid shares
+	shares[4] = sks[5].SignShare(msg)
+	sig, _, err = AggregateAndVerifySigWithKeys(pk, vks, msg, threshold, shares, scaler)
+	shareErr, ok := err.(*IndexedError)
+	if sig != nil || !ok || len(shareErr.Indices) != 3 {
+		t.Fatalf("aggregated with not enough valid shares")
+	}
+}
+
+func TestMinSigThresholdSignature(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKGWithMode(size, threshold, MinSigSize)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	sks := dkg.GetPrivateKeysFromPrepare()
+	pk := dkg.PublishGlobalPublicKey()
+	vks := dkg.PublishVerificationKeys()
+	scaler := dkg.GetScaler()
+
+	msg := []byte("pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza pizza")
+	shares := make(map[int]*SignatureShare)
+	for i := 1; i <= len(sks); i++ {
+		shares[i] = sks[i].SignShareWithMode(msg, MinSigSize)
+		if !vks[i].VerifySigShare(msg, shares[i]) {
+			t.Fatalf("invalid signature share")
+		}
+	}
+
+	// Aggregate with and without verification keys
+	sig, _, err := AggregateAndVerifySigWithMode(pk, vks, msg, threshold, shares, scaler, MinSigSize)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	other, _, err := AggregateAndVerifySigWithMode(pk, nil, msg, threshold, shares, scaler, MinSigSize)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !sig.Equals(other) {
+		t.Fatalf("inconsistent signatures")
+	}
+
+	// Signature is 48 bytes
+	b := sig.ToBytes()
+	if len(b) != 48 {
+		t.Fatalf("unexpected signature size %d", len(b))
+	}
+	sig, err = BytesToSig(b)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !pk.VerifySig(msg, sig) {
+		t.Fatalf("invalid signature")
+	}
+	if pk.VerifySig([]byte("pizza"), sig) {
+		t.Fatalf("signature verified for a wrong message")
+	}
+
+	// Shares of the other mode are dropped
+	shares[3] = sks[3].SignShare(msg)
+	sig, invalid, err := AggregateAndVerifySigWithMode(pk, vks, msg, threshold, shares, scaler, MinSigSize)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !pk.VerifySig(msg, sig) {
+		t.Fatalf("invalid signature")
+	}
+	if len(invalid) != 1 || invalid[0] != 3 {
+		t.Fatalf("unexpected invalid shares %v", invalid)
+	}
+}
+
+func TestVerifySigBatch(t *testing.T) {
+	sk := &PrivateKey{
+		fr: RandScalar(),
+	}
+	pk := sk.GetPublicKey()
+
+	for _, mode := range []SignatureMode{MinPubKeySize, MinSigSize} {
+		// Some messages repeat
+		msgs := make([][]byte, 20)
+		sigs := make([]*Signature, len(msgs))
+		for i := 0; i < len(msgs); i++ {
+			msgs[i] = []byte{byte(i % 7)}
+			sigs[i] = (*Signature)(sk.SignShareWithMode(msgs[i], mode))
+		}
+		if err := pk.VerifySigBatch(msgs, sigs); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		// Put wrong signatures
+		sigs[3] = sigs[4]
+		sigs[17] = (*Signature)(sk.SignShareWithMode([]byte("pizza"), mode))
+		err := pk.VerifySigBatch(msgs, sigs)
+		batchErr, ok := err.(*IndexedError)
+		if !ok || len(batchErr.Indices) != 2 || batchErr.Indices[0] != 3 || batchErr.Indices[1] != 17 {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if err := pk.VerifySigBatch(make([][]byte, 2), make([]*Signature, 3)); err == nil {
+		t.Fatalf("mismatched batch accepted")
+	}
+}
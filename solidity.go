@@ -0,0 +1,156 @@
+package tpke
+
+import (
+	"encoding/hex"
+	"math/big"
+	"regexp"
+	"strings"
+	"text/template"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+var fpModulus, _ = new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+
+var solidityIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// GenerateSolidityVerifier emits a contract which verifies threshold signatures of the mode and decryptions of a committee.
+// The contract is deployed with the EIP-2537 encodings of the global public key in G1 and G2 and a DST, e.g. from SigningContext.DST.
+// Calls go to EIP-2537 precompiles at 0x0b-0x11, and the hash to curve follows the one of SignShare.
+func GenerateSolidityVerifier(name string, mode SignatureMode) (string, error) {
+	if !solidityIdentifier.MatchString(name) {
+		return "", NewEIP2537ContractError()
+	}
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	p := make([]byte, eip2537FpSize)
+	eip2537EncodeFp(p, fpModulus.Bytes())
+	params := map[string]interface{}{
+		"Name":   name,
+		"MinSig": mode == MinSigSize,
+		"P":      hex.EncodeToString(p),
+		"G1":     hex.EncodeToString(eip2537EncodeG1(g1.One())),
+		"NegG1":  hex.EncodeToString(eip2537EncodeG1(g1.Neg(g1.New(), g1.One()))),
+		"NegG2":  hex.EncodeToString(eip2537EncodeG2(g2.Neg(g2.New(), g2.One()))),
+	}
+	var sb strings.Builder
+	if err := solidityVerifierTemplate.Execute(&sb, params); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+var solidityVerifierTemplate = template.Must(template.New("verifier").Parse(`// SPDX-License-Identifier: MIT
+// Code generated by tpke. DO NOT EDIT.
+pragma solidity ^0.8.19;
+
+// {{.Name}} verifies threshold BLS signatures in {{if .MinSig}}G1{{else}}G2{{end}} and threshold decryptions with EIP-2537 precompiles.
+// All points are in EIP-2537 layout, e.g. from Signature.ToEIP2537.
+contract {{.Name}} {
+    address private constant MOD_EXP = address(0x05);
+    address private constant G1_ADD = address(0x0b);
+    address private constant G2_ADD = address(0x0d);
+    address private constant PAIRING = address(0x0f);
+    address private constant MAP_FP_TO_G1 = address(0x10);
+    address private constant MAP_FP2_TO_G2 = address(0x11);
+
+    // Base field modulus
+    bytes private constant P = hex"{{.P}}";
+    // Generator of G1 and negated generators
+    bytes private constant G1 = hex"{{.G1}}";
+    bytes private constant NEG_G1 = hex"{{.NegG1}}";
+    bytes private constant NEG_G2 = hex"{{.NegG2}}";
+
+    bytes public publicKey;
+    bytes public publicKeyG2;
+    bytes public dst;
+
+    constructor(bytes memory _publicKey, bytes memory _publicKeyG2, bytes memory _dst) {
+        require(_publicKey.length == 128, "invalid public key");
+        require(_publicKeyG2.length == 256{{if not .MinSig}} || _publicKeyG2.length == 0{{end}}, "invalid public key");
+        require(_dst.length > 0 && _dst.length < 256, "invalid dst");
+        publicKey = _publicKey;
+        publicKeyG2 = _publicKeyG2;
+        dst = _dst;
+    }
+
+    // verify checks a threshold signature on message against the global public key
+    function verify(bytes calldata message, bytes calldata signature) external view returns (bool) {
+{{- if .MinSig}}
+        require(signature.length == 128, "invalid signature");
+        bytes memory pk = publicKeyG2;
+        // e(H(m),pk)*e(S,-G2)==1
+        return pairing(abi.encodePacked(hashToG1(message), pk, signature, NEG_G2));
+{{- else}}
+        require(signature.length == 256, "invalid signature");
+        bytes memory pk = publicKey;
+        // e(pk,H(m))*e(-G1,S)==1
+        return pairing(abi.encodePacked(pk, hashToG2(message), NEG_G1, signature));
+{{- end}}
+    }
+
+    // verifyDecryption checks that message is the plaintext of a standard ciphertext, with the proof from DecryptWithProof
+    function verifyDecryption(bytes calldata cipherText, bytes calldata message, bytes calldata proof) external view returns (bool) {
+        require(cipherText.length == 512 && message.length == 128 && proof.length == 128, "invalid input");
+        // e(R1,-G2)*e(G1,R2)==1
+        if (!pairing(abi.encodePacked(cipherText[128:256], NEG_G2, G1, cipherText[256:512]))) {
+            return false;
+        }
+        // C==M+rpk
+        bytes memory sum = precompile(G1_ADD, abi.encodePacked(message, proof), 128);
+        if (keccak256(sum) != keccak256(cipherText[0:128])) {
+            return false;
+        }
+        bytes memory pk = publicKey;
+        // e(pk,R2)*e(rpk,-G2)==1
+        return pairing(abi.encodePacked(pk, cipherText[256:512], proof, NEG_G2));
+    }
+{{if .MinSig}}
+    function hashToG1(bytes calldata message) private view returns (bytes memory) {
+        bytes memory uniform = expandMessage(message, 128);
+        bytes memory q0 = precompile(MAP_FP_TO_G1, reduce(uniform, 0), 128);
+        bytes memory q1 = precompile(MAP_FP_TO_G1, reduce(uniform, 64), 128);
+        return precompile(G1_ADD, abi.encodePacked(q0, q1), 128);
+    }
+{{else}}
+    function hashToG2(bytes calldata message) private view returns (bytes memory) {
+        bytes memory uniform = expandMessage(message, 256);
+        bytes memory q0 = precompile(MAP_FP2_TO_G2, abi.encodePacked(reduce(uniform, 0), reduce(uniform, 64)), 256);
+        bytes memory q1 = precompile(MAP_FP2_TO_G2, abi.encodePacked(reduce(uniform, 128), reduce(uniform, 192)), 256);
+        return precompile(G2_ADD, abi.encodePacked(q0, q1), 256);
+    }
+{{end}}
+    // expand_message_xmd with SHA-256
+    function expandMessage(bytes calldata message, uint256 length) private view returns (bytes memory out) {
+        bytes memory tag = dst;
+        bytes memory dstPrime = abi.encodePacked(tag, uint8(tag.length));
+        bytes32 b0 = sha256(abi.encodePacked(new bytes(64), message, uint16(length), uint8(0), dstPrime));
+        bytes32 bi = sha256(abi.encodePacked(b0, uint8(1), dstPrime));
+        out = abi.encodePacked(bi);
+        for (uint256 i = 2; i <= length / 32; i++) {
+            bi = sha256(abi.encodePacked(b0 ^ bi, uint8(i), dstPrime));
+            out = abi.encodePacked(out, bi);
+        }
+    }
+
+    // Reduce 64 bytes of b from offset modulo p
+    function reduce(bytes memory b, uint256 offset) private view returns (bytes memory) {
+        bytes memory x = new bytes(64);
+        for (uint256 i = 0; i < 64; i++) {
+            x[i] = b[offset + i];
+        }
+        return precompile(MOD_EXP, abi.encodePacked(uint256(64), uint256(1), uint256(64), x, uint8(1), P), 64);
+    }
+
+    function pairing(bytes memory input) private view returns (bool) {
+        bytes memory out = precompile(PAIRING, input, 32);
+        return uint256(bytes32(out)) == 1;
+    }
+
+    function precompile(address target, bytes memory input, uint256 length) private view returns (bytes memory) {
+        (bool ok, bytes memory out) = target.staticcall(input);
+        require(ok && out.length == length, "precompile failed");
+        return out;
+    }
+}
+`))
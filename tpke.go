@@ -1,32 +1,86 @@
 package tpke
 
 import (
-	"math"
-	"math/big"
+	"encoding/binary"
+	"sort"
 
 	bls "github.com/kilic/bls12-381"
 )
 
 var fpByteSize = 48
+var frByteSize = 32
+var epochByteSize = 8
+
+type CipherTextFormat byte
+
+const (
+	StandardFormat CipherTextFormat = iota // Carries R2=rG2, verified by pairings
+	CompactFormat                          // Carries a Schnorr proof of r over G1
+)
+
+// Version tag of compact bytes, standard bytes start with a compressed point flag instead
+var compactVersion byte = 0x01
+
+var encryptionDomain = []byte("TPKE_ENCRYPTION_PROOF_SHA-256_")
 
 type CipherText struct {
-	cMsg          *bls.PointG1
-	bigR          *bls.PointG1
-	commitment    *bls.PointG2
-	fromLastRound bool
+	cMsg       *bls.PointG1
+	bigR       *bls.PointG1
+	commitment *bls.PointG2     // Only in standard format
+	proof      *encryptionProof // Only in compact format
+	epoch      uint64           // Key epoch of the public key used for encryption
+}
+
+// Proof of knowledge of r, as (c,z) with c=H(pk,epoch,C,R1,zG1-cR1), so that it is not replayed under another key or epoch
+type encryptionProof struct {
+	c *bls.Fr
+	z *bls.Fr
+}
+
+func (ct *CipherText) Epoch() uint64 {
+	return ct.epoch
+}
+
+func (ct *CipherText) Format() CipherTextFormat {
+	if ct.commitment == nil && ct.proof != nil {
+		return CompactFormat
+	}
+	return StandardFormat
 }
 
 func (ct *CipherText) ToBytes() []byte {
-	out := make([]byte, 4*fpByteSize)
 	g1 := bls.NewG1()
+	if ct.Format() == CompactFormat {
+		out := make([]byte, 1+2*fpByteSize+2*frByteSize+epochByteSize)
+		out[0] = compactVersion
+		copy(out[1:1+fpByteSize], g1.ToCompressed(ct.cMsg))
+		copy(out[1+fpByteSize:1+2*fpByteSize], g1.ToCompressed(ct.bigR))
+		copy(out[1+2*fpByteSize:1+2*fpByteSize+frByteSize], ct.proof.c.ToBytes())
+		copy(out[1+2*fpByteSize+frByteSize:1+2*fpByteSize+2*frByteSize], ct.proof.z.ToBytes())
+		binary.BigEndian.PutUint64(out[1+2*fpByteSize+2*frByteSize:], ct.epoch)
+		return out
+	}
+	out := make([]byte, 4*fpByteSize+epochByteSize)
 	g2 := bls.NewG2()
 	copy(out[:fpByteSize], g1.ToCompressed(ct.cMsg))
 	copy(out[fpByteSize:2*fpByteSize], g1.ToCompressed(ct.bigR))
 	copy(out[2*fpByteSize:4*fpByteSize], g2.ToCompressed(ct.commitment))
+	binary.BigEndian.PutUint64(out[4*fpByteSize:], ct.epoch)
 	return out
 }
 
+// Bytes without the epoch suffix are accepted and decoded as epoch 0
 func BytesToCipherText(b []byte) (*CipherText, error) {
+	if len(b) > 0 && b[0] == compactVersion {
+		return bytesToCompactCipherText(b)
+	}
+	if len(b) != 4*fpByteSize && len(b) != 4*fpByteSize+epochByteSize {
+		return nil, NewTPKECiphertextError()
+	}
+	epoch := uint64(0)
+	if len(b) == 4*fpByteSize+epochByteSize {
+		epoch = binary.BigEndian.Uint64(b[4*fpByteSize:])
+	}
 	g1 := bls.NewG1()
 	g2 := bls.NewG2()
 	cMsg, err := g1.FromCompressed(b[:fpByteSize])
@@ -45,24 +99,142 @@ func BytesToCipherText(b []byte) (*CipherText, error) {
 		cMsg:       cMsg,
 		bigR:       bigR,
 		commitment: commitment,
+		epoch:      epoch,
 	}, nil
 }
 
+func bytesToCompactCipherText(b []byte) (*CipherText, error) {
+	if len(b) != 1+2*fpByteSize+2*frByteSize+epochByteSize {
+		return nil, NewTPKECiphertextError()
+	}
+	b = b[1:]
+	g1 := bls.NewG1()
+	cMsg, err := g1.FromCompressed(b[:fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	bigR, err := g1.FromCompressed(b[fpByteSize : 2*fpByteSize])
+	if err != nil {
+		return nil, err
+	}
+	c, err := bytesToFr(b[2*fpByteSize : 2*fpByteSize+frByteSize])
+	if err != nil {
+		return nil, err
+	}
+	z, err := bytesToFr(b[2*fpByteSize+frByteSize : 2*fpByteSize+2*frByteSize])
+	if err != nil {
+		return nil, err
+	}
+	return &CipherText{
+		cMsg: cMsg,
+		bigR: bigR,
+		proof: &encryptionProof{
+			c: c,
+			z: z,
+		},
+		epoch: binary.BigEndian.Uint64(b[2*fpByteSize+2*frByteSize:]),
+	}, nil
+}
+
+// Verify checks a standard ciphertext, a compact one is bound to its public key and checked by PublicKey.VerifyCipherText
 func (ct *CipherText) Verify() error {
+	if ct.Format() == CompactFormat {
+		return NewTPKECiphertextKeyError()
+	}
+	if ct.commitment == nil {
+		return NewTPKECiphertextError()
+	}
 	// User sends an invalid commitment for his random r
-	pairing := bls.NewEngine()
-	e1 := pairing.AddPair(ct.bigR, &bls.G2One).Result()
-	e2 := pairing.AddPair(&bls.G1One, ct.commitment).Result()
-	if !e1.Equal(e2) {
+	if !pairingCheck(ct.bigR, &bls.G2One, &bls.G1One, ct.commitment) {
 		return NewTPKECiphertextError()
 	}
 	return nil
 }
 
+// VerifyCipherText checks a ciphertext of either format
+func (pk *PublicKey) VerifyCipherText(ct *CipherText) error {
+	if ct.Format() != CompactFormat {
+		return ct.Verify()
+	}
+	// User sends an invalid proof for his random r, c!=H(pk,epoch,C,R1,zG1-cR1)
+	g1 := bls.NewG1()
+	bigA := g1FixedBase().mul(ct.proof.z)
+	cR := g1.New()
+	g1.MulScalar(cR, ct.bigR, ct.proof.c)
+	g1.Sub(bigA, bigA, cR)
+	if !encryptionChallenge(pk, ct.epoch, ct.cMsg, ct.bigR, bigA).Equal(ct.proof.c) {
+		return NewTPKECiphertextError()
+	}
+	return nil
+}
+
+// VerifyBatch checks all ciphertexts with one multi-pairing, and locates invalid ones by binary search if the batch fails.
+// Compact ciphertexts are checked against pub one by one.
+func VerifyBatch(cts []*CipherText, pub *PublicKey) error {
+	invalid := make([]int, 0)
+	standard := make([]int, 0, len(cts))
+	for i := 0; i < len(cts); i++ {
+		switch {
+		case cts[i].Format() == CompactFormat:
+			if pub.VerifyCipherText(cts[i]) != nil {
+				invalid = append(invalid, i)
+			}
+		case cts[i].commitment == nil:
+			invalid = append(invalid, i)
+		default:
+			standard = append(standard, i)
+		}
+	}
+	invalid = append(invalid, searchInvalidCipherTexts(cts, standard)...)
+	if len(invalid) > 0 {
+		sort.Ints(invalid)
+		return NewTPKEBatchCiphertextError(invalid)
+	}
+	return nil
+}
+
+func searchInvalidCipherTexts(cts []*CipherText, indices []int) []int {
+	if len(indices) == 0 || verifyCipherTextsOnce(cts, indices) {
+		return nil
+	}
+	if len(indices) == 1 {
+		return indices
+	}
+	mid := len(indices) / 2
+	return append(searchInvalidCipherTexts(cts, indices[:mid]), searchInvalidCipherTexts(cts, indices[mid:])...)
+}
+
+func verifyCipherTextsOnce(cts []*CipherText, indices []int) bool {
+	// e(sum(di*R1i),G2)==e(G1,sum(di*R2i)) with random small di
+	bigRs := make([]*bls.PointG1, len(indices))
+	commitments := make([]*bls.PointG2, len(indices))
+	for i, index := range indices {
+		bigRs[i] = cts[index].bigR
+		commitments[i] = cts[index].commitment
+	}
+	scalars := randBatchScalars(len(indices))
+	return pairingCheck(multiExpG1(bigRs, scalars), &bls.G2One, &bls.G1One, multiExpG2(commitments, scalars))
+}
+
+func encryptionChallenge(pk *PublicKey, epoch uint64, cMsg *bls.PointG1, bigR *bls.PointG1, bigA *bls.PointG1) *bls.Fr {
+	g1 := bls.NewG1()
+	e := make([]byte, epochByteSize)
+	binary.BigEndian.PutUint64(e, epoch)
+	return hashToFr(encryptionDomain, g1.ToCompressed(pk.pg1), e, g1.ToCompressed(cMsg), g1.ToCompressed(bigR), g1.ToCompressed(bigA))
+}
+
 func Encrypt(msgs []*bls.PointG1, pub *PublicKey) []*CipherText {
+	return EncryptWithFormat(msgs, pub, StandardFormat)
+}
+
+func EncryptWithFormat(msgs []*bls.PointG1, pub *PublicKey, format CipherTextFormat) []*CipherText {
 	results := make([]*CipherText, len(msgs))
 	for i := 0; i < len(msgs); i++ {
-		results[i] = pub.Encrypt(msgs[i])
+		if format == CompactFormat {
+			results[i] = pub.EncryptCompact(msgs[i])
+		} else {
+			results[i] = pub.Encrypt(msgs[i])
+		}
 	}
 	return results
 }
@@ -121,102 +293,244 @@ func parallelDecryptShare(index int, key *PrivateKey, cts []*CipherText, ch chan
 	}
 }
 
-// PublicKey is used for immediate verification, method returns error if all combinations of shares fail
+// PublicKey is used for immediate verification. Corrupted shares are located by decoding, and any threshold honest shares are searched for beyond that.
+// Without verification keys the search is bounded by maxDecodingAttempts, DecryptWithKeys checks every share in polynomial time instead.
 func Decrypt(cts []*CipherText, inputs map[int]([]*DecryptionShare), pub *PublicKey, threshold int, scaler int) ([]*bls.PointG1, error) {
+	results, _, err := DecryptWithProof(cts, inputs, pub, threshold, scaler)
+	return results, err
+}
+
+// DecryptWithProof also outputs a proof for every plaintext, which anyone with the global public key can check
+func DecryptWithProof(cts []*CipherText, inputs map[int]([]*DecryptionShare), pub *PublicKey, threshold int, scaler int) ([]*bls.PointG1, []*DecryptionProof, error) {
 	if len(inputs) < threshold {
-		return nil, NewTPKENotEnoughShareError()
+		return nil, nil, NewTPKENotEnoughShareError()
 	}
 
-	matrix := make([][]int, len(inputs))              // size=len(inputs)*threshold, including all rows
-	shares := make([][]*DecryptionShare, len(inputs)) // size=len(inputs)*len(cts), including all shares
-
-	// Be aware of a random order of decryption shares
-	i := 0
+	// Be aware of a random order of decryption shares, and of participants without a share of every ciphertext
+	indices := make([]int, 0, len(inputs))
 	for index, v := range inputs {
-		row := make([]int, threshold)
-		for j := 0; j < threshold; j++ {
-			row[j] = int(math.Pow(float64(index), float64(j)))
+		if len(v) == len(cts) {
+			indices = append(indices, index)
 		}
-		matrix[i] = row
-		shares[i] = v
-		i++
 	}
+	sort.Ints(indices)
 
-	// Use different combinations to decrypt
-	combs := getCombs(len(inputs), threshold)
-	for _, v := range combs {
-		m := make([][]int, threshold)              // size=threshold*threshold, only seleted rows
-		s := make([][]*DecryptionShare, threshold) // size=threshold*len(cts), only seleted shares
-		for i := 0; i < len(v); i++ {
-			m[i] = matrix[v[i]]
-			s[i] = shares[v[i]]
+	// Corrupted shares are located first, within the search bound
+	if invalid, err := LocateInvalidDecryptionShares(cts, inputs, threshold); err == nil {
+		valid := make([]int, 0, len(indices))
+		for _, index := range indices {
+			if len(invalid) > 0 && invalid[0] == index {
+				invalid = invalid[1:]
+				continue
+			}
+			valid = append(valid, index)
 		}
-		results, err := tryDecrypt(cts, m, s, pub, scaler)
-		if err == nil {
-			return results, nil
+		if len(valid) >= threshold {
+			results, proofs, err := decryptWithShares(cts, inputs, valid[:threshold], pub, scaler)
+			if err == nil {
+				return results, proofs, nil
+			}
 		}
 	}
-	return nil, NewTPKEDecryptionError()
+
+	// Beyond that, threshold honest shares are searched for
+	var results []*bls.PointG1
+	var proofs []*DecryptionProof
+	_, err := searchShares(len(indices), threshold, func(positions []int) bool {
+		selected := make([]int, len(positions))
+		for i, pos := range positions {
+			selected[i] = indices[pos]
+		}
+		var err error
+		results, proofs, err = decryptWithShares(cts, inputs, selected, pub, scaler)
+		return err == nil
+	})
+	if err != nil {
+		return nil, nil, NewTPKESearchError()
+	}
+	return results, proofs, nil
 }
 
-func tryDecrypt(cts []*CipherText, matrix [][]int, shares [][]*DecryptionShare, pub *PublicKey, scaler int) ([]*bls.PointG1, error) {
-	// Be aware of the integer overflow when the size and threshold of tpke grow big
-	d, coeff := feldman(matrix)
-	d = scaler / d
-	results := make([]*bls.PointG1, len(cts))
-	// Compute M=C-d1/d
-	denominator := bls.NewFr().FromBytes(big.NewInt(int64(abs(d))).Bytes())
-	if d < 0 {
-		denominator.Neg(denominator)
+// DecryptWithKeys checks the shares of every participant against its verification key, so that any threshold valid ones decrypt in polynomial time.
+// Invalid participants are returned apart from the error, which is an IndexedError of them if not enough valid ones are left.
+// Compact ciphertexts carry no R2, so the keys need their G2 form then.
+func DecryptWithKeys(cts []*CipherText, inputs map[int]([]*DecryptionShare), pub *PublicKey, vks map[int]*PublicKey, threshold int, scaler int) ([]*bls.PointG1, []*DecryptionProof, []int, error) {
+	if len(inputs) < threshold {
+		return nil, nil, nil, NewTPKENotEnoughShareError()
+	}
+	// Shares of all ciphertexts are folded with random weights, and checked by
+	// e(sum(wj*Sj),G2)==e(vk,sum(wj*R2j))*e(sum(wj*R1j),vk') over standard and compact ones
+	weights := randBatchScalars(len(cts))
+	var standardR2s []*bls.PointG2
+	var standardWeights, compactWeights []*bls.Fr
+	var compactR1s []*bls.PointG1
+	for j, ct := range cts {
+		if ct.Format() == CompactFormat {
+			compactR1s = append(compactR1s, ct.bigR)
+			compactWeights = append(compactWeights, weights[j])
+		} else {
+			standardR2s = append(standardR2s, ct.commitment)
+			standardWeights = append(standardWeights, weights[j])
+		}
+	}
+	var r2 *bls.PointG2
+	var r1 *bls.PointG1
+	if len(standardR2s) > 0 {
+		r2 = multiExpG2(standardR2s, standardWeights)
+	}
+	if len(compactR1s) > 0 {
+		r1 = multiExpG1(compactR1s, compactWeights)
+	}
+
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	valid := make([]int, 0, len(inputs))
+	invalid := make([]int, 0)
+	for index, v := range inputs {
+		vk, ok := vks[index]
+		if !ok || len(v) != len(cts) {
+			invalid = append(invalid, index)
+			continue
+		}
+		if r1 != nil && vk.pg2 == nil {
+			return nil, nil, nil, NewTPKEPublicKeyG2Error()
+		}
+		points := make([]*bls.PointG1, len(cts))
+		for j := range cts {
+			points[j] = v[j].pg1
+		}
+		pairing := bls.NewEngine()
+		pairing.AddPair(multiExpG1(points, weights), g2.One())
+		if r2 != nil {
+			pairing.AddPairInv(g1.New().Set(vk.pg1), g2.New().Set(r2))
+		}
+		if r1 != nil {
+			pairing.AddPairInv(g1.New().Set(r1), g2.New().Set(vk.pg2))
+		}
+		if pairing.Check() {
+			valid = append(valid, index)
+		} else {
+			invalid = append(invalid, index)
+		}
+	}
+	sort.Ints(valid)
+	sort.Ints(invalid)
+	if len(valid) < threshold {
+		return nil, nil, invalid, NewTPKEInvalidShareError(invalid)
+	}
+	results, proofs, err := decryptWithShares(cts, inputs, valid[:threshold], pub, scaler)
+	if err != nil {
+		return nil, nil, invalid, err
+	}
+	return results, proofs, invalid, nil
+}
+
+// Decrypt with the shares of threshold participants, and verify the results
+func decryptWithShares(cts []*CipherText, inputs map[int]([]*DecryptionShare), indices []int, pub *PublicKey, scaler int) ([]*bls.PointG1, []*DecryptionProof, error) {
+	s := make([][]*DecryptionShare, len(indices)) // size=threshold*len(cts), only seleted shares
+	for i, index := range indices {
+		s[i] = inputs[index]
+	}
+	return tryDecrypt(cts, indices, s, pub, scaler)
+}
+
+func tryDecrypt(cts []*CipherText, indices []int, shares [][]*DecryptionShare, pub *PublicKey, scaler int) ([]*bls.PointG1, []*DecryptionProof, error) {
+	// Compute M=C-scaler*sum(li*Si) with lagrange coefficients over Fr
+	scalars, err := lagrangeCoefficients(indices)
+	if err != nil {
+		return nil, nil, err
 	}
+	// Factors of shares are -scaler*li, the same for all ciphertexts
+	negScaler := frFromInt(-scaler)
+	for j := range scalars {
+		scalars[j].Mul(scalars[j], negScaler)
+	}
+	results := make([]*bls.PointG1, len(cts))
+	proofs := make([]*DecryptionProof, len(cts))
 	ch := make(chan verifyMessage, len(cts))
 	g1 := bls.NewG1()
+	points := make([]*bls.PointG1, len(shares))
 	for i := 0; i < len(cts); i++ {
-		rpk := g1.Zero()
-		// Add up shares with some factors as d1, and plus -1
+		// Add up shares with factors -scaler*li
 		for j := 0; j < len(shares); j++ {
-			minor := g1.New()
-			g1.MulScalar(minor, shares[j][i].pg1, bls.NewFr().FromBytes(big.NewInt(int64(abs(coeff[j]))).Bytes()))
-			if coeff[j] > 0 {
-				g1.Neg(minor, minor)
-			}
-			g1.Add(rpk, rpk, minor)
+			points[j] = shares[j][i].pg1
 		}
-		// Divide -d1 by d
-		g1.MulScalar(rpk, rpk, denominator)
+		rpk := multiExpG1(points, scalars)
 		// Decrypt
 		results[i] = g1.Add(g1.Zero(), cts[i].cMsg, rpk)
+		proofs[i] = &DecryptionProof{
+			rpk: g1.Neg(g1.New(), rpk),
+		}
 		// Verify the decryption
-		go parallelVerify(i, cts[i], pub.pg1, rpk, ch)
+		go parallelVerify(i, cts[i], pub, proofs[i].rpk, ch)
 	}
 	for i := 0; i < len(cts); i++ {
 		msg := <-ch
 		if msg.err != nil {
-			return nil, msg.err
+			return nil, nil, msg.err
 		}
 	}
 
-	return results, nil
+	return results, proofs, nil
 }
 
-func parallelVerify(index int, ct *CipherText, pk *bls.PointG1, rpk *bls.PointG1, ch chan<- verifyMessage) {
-	// User sends an invalid commitment for his random r
-	g2 := bls.NewG2()
-	pairing := bls.NewEngine()
-	cmt := g2.New()
-	g2.Neg(cmt, ct.commitment)
-	// Decrypted rpk is not correct, e(pk,rG2)!=e(rpk,G2), decryption fails
-	e1 := pairing.AddPair(pk, cmt).Result()
-	e2 := pairing.AddPair(rpk, &bls.G2One).Result()
-	if !e1.Equal(e2) {
-		ch <- verifyMessage{
-			index: index,
-			err:   NewTPKEDecryptionError(),
-		}
-		return
-	}
+func parallelVerify(index int, ct *CipherText, pub *PublicKey, rpk *bls.PointG1, ch chan<- verifyMessage) {
 	ch <- verifyMessage{
 		index: index,
-		err:   nil,
+		err:   verifyRPK(ct, pub, rpk),
+	}
+}
+
+func verifyRPK(ct *CipherText, pub *PublicKey, rpk *bls.PointG1) error {
+	var valid bool
+	if ct.Format() == CompactFormat {
+		if pub.pg2 == nil {
+			return NewTPKEPublicKeyG2Error()
+		}
+		// Decrypted rpk is not correct, e(R1,pk')!=e(rpk,G2), decryption fails
+		valid = pairingCheck(ct.bigR, pub.pg2, rpk, &bls.G2One)
+	} else {
+		// Decrypted rpk is not correct, e(pk,rG2)!=e(rpk,G2), decryption fails
+		valid = pairingCheck(pub.pg1, ct.commitment, rpk, &bls.G2One)
+	}
+	if !valid {
+		return NewTPKEDecryptionError()
 	}
+	return nil
+}
+
+// DecryptionProof is the recovered rpk of a ciphertext, M=C-rpk
+type DecryptionProof struct {
+	rpk *bls.PointG1
+}
+
+func (p *DecryptionProof) ToBytes() []byte {
+	return bls.NewG1().ToCompressed(p.rpk)
+}
+
+func BytesToDecryptionProof(b []byte) (*DecryptionProof, error) {
+	rpk, err := bls.NewG1().FromCompressed(b)
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptionProof{
+		rpk: rpk,
+	}, nil
+}
+
+// VerifyDecryption checks a claimed plaintext of a ciphertext with only the global public key.
+// A compact ciphertext carries no R2, so the G2 form of the key is needed, e.g. from DKG.PublishGlobalPublicKey.
+func (pk *PublicKey) VerifyDecryption(ct *CipherText, msg *bls.PointG1, proof *DecryptionProof) error {
+	if err := pk.VerifyCipherText(ct); err != nil {
+		return err
+	}
+	if err := verifyRPK(ct, pk, proof.rpk); err != nil {
+		return err
+	}
+	// C==M+rpk
+	g1 := bls.NewG1()
+	if !g1.Equal(ct.cMsg, g1.Add(g1.New(), msg, proof.rpk)) {
+		return NewTPKEDecryptionError()
+	}
+	return nil
 }
@@ -63,7 +63,6 @@ func TestReshareTPKEOld(t *testing.T) {
 	msg := make([]*bls.PointG1, 1)
 	msg[0] = RandPG1()
 	cipherTexts := Encrypt(msg, pubkey)
-	cipherTexts[0].fromLastRound = true
 
 	// Verify ciphertext
 	if err := cipherTexts[0].Verify(); err != nil {
@@ -110,7 +109,6 @@ func TestReshareTPKENew(t *testing.T) {
 	msg := make([]*bls.PointG1, 1)
 	msg[0] = RandPG1()
 	cipherTexts := Encrypt(msg, pubkey)
-	cipherTexts[0].fromLastRound = false
 
 	// Verify ciphertext
 	if err := cipherTexts[0].Verify(); err != nil {
@@ -154,3 +152,144 @@ func TestBytesEncoding(t *testing.T) {
 		t.Fatalf("commitment mismatch.")
 	}
 }
+
+func TestCompactCipherText(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+
+	// Encrypt
+	msg := make([]*bls.PointG1, 1)
+	msg[0] = RandPG1()
+	cipherTexts := EncryptWithFormat(msg, pubkey, CompactFormat)
+	b := cipherTexts[0].ToBytes()
+	if len(b) >= len(pubkey.Encrypt(msg[0]).ToBytes()) {
+		t.Fatalf("ciphertext is not compact.")
+	}
+	ct, err := BytesToCipherText(b)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if ct.Format() != CompactFormat {
+		t.Fatalf("format mismatch.")
+	}
+	cipherTexts[0] = ct
+
+	// Verify ciphertext
+	if err := pubkey.VerifyCipherText(cipherTexts[0]); err != nil {
+		t.Fatalf("invalid ciphertext.")
+	}
+	if err := cipherTexts[0].Verify(); err == nil {
+		t.Fatalf("compact ciphertext verified without a key.")
+	}
+	forged := &CipherText{
+		cMsg:  RandPG1(),
+		bigR:  ct.bigR,
+		proof: ct.proof,
+	}
+	if err := pubkey.VerifyCipherText(forged); err == nil {
+		t.Fatalf("forged ciphertext accepted.")
+	}
+	// The proof is bound to the key and the epoch
+	if err := (&PrivateKey{fr: RandScalar()}).GetPublicKey().VerifyCipherText(ct); err == nil {
+		t.Fatalf("ciphertext accepted under another key.")
+	}
+	replayed := *ct
+	replayed.epoch = 1
+	if err := pubkey.VerifyCipherText(&replayed); err == nil {
+		t.Fatalf("ciphertext accepted in another epoch.")
+	}
+
+	// Generate shares
+	shares := decryptShare(cipherTexts, prvkeys)
+
+	// Put a wrong share
+	shares[2][0].pg1 = RandPG1()
+
+	// Decrypt
+	results, err := Decrypt(cipherTexts, shares, pubkey, threshold, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !bls.NewG1().Equal(msg[0], results[0]) {
+		t.Fatalf("decryption failed.")
+	}
+}
+
+func TestVerifiableDecryption(t *testing.T) {
+	size := 7
+	threshold := 5
+	dkg := NewDKG(size, threshold)
+	dkg.Prepare()
+	if err := dkg.VerifyPrepare(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	pubkey := dkg.PublishGlobalPublicKey()
+	prvkeys := dkg.GetPrivateKeysFromPrepare()
+
+	// Encrypt in both formats
+	msg := []*bls.PointG1{RandPG1(), RandPG1()}
+	cipherTexts := []*CipherText{pubkey.Encrypt(msg[0]), pubkey.EncryptCompact(msg[1])}
+
+	// Decrypt
+	shares := decryptShare(cipherTexts, prvkeys)
+	results, proofs, err := DecryptWithProof(cipherTexts, shares, pubkey, threshold, dkg.GetScaler())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Verify with global public key only
+	for i := 0; i < len(cipherTexts); i++ {
+		proof, err := BytesToDecryptionProof(proofs[i].ToBytes())
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := pubkey.VerifyDecryption(cipherTexts[i], results[i], proof); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := pubkey.VerifyDecryption(cipherTexts[i], RandPG1(), proof); err == nil {
+			t.Fatalf("wrong plaintext accepted.")
+		}
+		if err := pubkey.VerifyDecryption(cipherTexts[i], results[i], &DecryptionProof{rpk: RandPG1()}); err == nil {
+			t.Fatalf("wrong proof accepted.")
+		}
+	}
+
+	// Compact format needs the G2 form of the key
+	err = (&PublicKey{pg1: pubkey.pg1}).VerifyDecryption(cipherTexts[1], results[1], proofs[1])
+	if err == nil || err.Error() != NewTPKEPublicKeyG2Error().Error() {
+		t.Fatalf("unexpected error %v.", err)
+	}
+}
+
+func TestVerifyBatch(t *testing.T) {
+	pubkey := (&PrivateKey{fr: RandScalar()}).GetPublicKey()
+	msgs := make([]*bls.PointG1, 50)
+	for i := range msgs {
+		msgs[i] = RandPG1()
+	}
+	cipherTexts := Encrypt(msgs, pubkey)
+	cipherTexts = append(cipherTexts, EncryptWithFormat(msgs[:10], pubkey, CompactFormat)...)
+	if err := VerifyBatch(cipherTexts, pubkey); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// Put invalid ciphertexts
+	cipherTexts[3].commitment = pubkey.Encrypt(msgs[3]).commitment
+	cipherTexts[31].bigR = RandPG1()
+	cipherTexts[55].cMsg = RandPG1()
+	err := VerifyBatch(cipherTexts, pubkey)
+	batchErr, ok := err.(*IndexedError)
+	if !ok {
+		t.Fatalf("unexpected error %v.", err)
+	}
+	if len(batchErr.Indices) != 3 || batchErr.Indices[0] != 3 || batchErr.Indices[1] != 31 || batchErr.Indices[2] != 55 {
+		t.Fatalf("wrong indices %v.", batchErr.Indices)
+	}
+}
@@ -2,99 +2,108 @@ package tpke
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
 	"errors"
 	"math"
 	"math/big"
+
+	bls "github.com/kilic/bls12-381"
+	"github.com/txhsl/tpke/polynomial"
 )
 
-func polyRecover(xs []int, ys []*big.Int) []*big.Int {
-	if len(xs) != len(ys) {
-		panic("array length mismatch")
-	}
-	// Compute lagrange
-	length := len(ys)
-	ns := make([][]int, length)
-	ds := make([]int, length)
-	for i := 0; i < length; i++ {
-		ns[i], ds[i] = lagrange(xs, i)
-	}
-	bigR := 1
-	for i := 0; i < length; i++ {
-		bigR *= ds[i]
-	}
-	for i := 0; i < length; i++ {
-		div := bigR / ds[i]
-		for j := 0; j < len(ns[i]); j++ {
-			ns[i][j] *= div
+// AES-256-GCM keyed by sha256(domain||secret). Secrets are fresh for every ciphertext, so a zero nonce is never reused.
+// Keys of 32 bytes never fail aes.NewCipher, and an AES block never fails cipher.NewGCM.
+func symmetricAEAD(domain []byte, secret []byte) cipher.AEAD {
+	hash := sha256.Sum256(append(append([]byte{}, domain...), secret...))
+	block, _ := aes.NewCipher(hash[:])
+	aead, _ := cipher.NewGCM(block)
+	return aead
+}
+
+// Hash to a scalar with 48 bytes of output, so that the bias of the reduction is negligible
+func hashToFr(domain []byte, inputs ...[]byte) *bls.Fr {
+	return bls.NewFr().FromBytes(expandMessageXMD(bytes.Join(inputs, nil), domain, 48))
+}
+
+// expand_message_xmd of RFC 9380 with SHA-256, outLen is at most 8160
+func expandMessageXMD(msg []byte, dst []byte, outLen int) []byte {
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+	h := sha256.New()
+	// b0=H(Z_pad||msg||l_i_b_str||0||DST_prime)
+	h.Write(make([]byte, h.BlockSize()))
+	h.Write(msg)
+	h.Write([]byte{byte(outLen >> 8), byte(outLen), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+	// bi=H((b0^b(i-1))||i||DST_prime), where b1 takes b0 as the previous block starts from zeros
+	out := make([]byte, 0, outLen+h.Size())
+	bi := make([]byte, h.Size())
+	for i := 1; len(out) < outLen; i++ {
+		h.Reset()
+		for j := range bi {
+			bi[j] ^= b0[j]
 		}
-		ds[i] = bigR
+		h.Write(bi)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
 	}
+	return out[:outLen]
+}
 
-	// Recover polynomial
-	t := make([]*big.Int, 0)
-	for i := 0; i < length; i++ {
-		poly := make([]*big.Int, len(ns[i]))
-		for j := 0; j < len(ns[i]); j++ {
-			poly[j] = new(big.Int).Mul(big.NewInt(int64(ns[i][j])), ys[i])
-		}
-		t = polyAdd(t, poly)
+// Reject non-canonical scalars, to keep encodings unique
+func bytesToFr(b []byte) (*bls.Fr, error) {
+	if new(big.Int).SetBytes(b).Cmp(bls.NewG1().Q()) >= 0 {
+		return nil, errors.New("scalar out of range")
 	}
-	for i := 0; i < len(t); i++ {
-		t[i] = new(big.Int).Div(t[i], big.NewInt(int64(bigR)))
-	}
-	return t
+	return bls.NewFr().FromBytes(b), nil
 }
 
-func lagrange(xs []int, n int) ([]int, int) {
-	numerator := []int{1}
-	for i := 0; i < len(xs); i++ {
-		if n == i {
-			continue
-		}
-		numerator = polyMul(numerator, []int{-xs[i], 1})
-	}
-	denominator := 1
-	for i := 0; i < len(xs); i++ {
-		if n == i {
-			continue
-		}
-		denominator *= xs[n] - xs[i]
+// pairingCheck verifies e(a,b)==e(c,d) as e(a,b)*e(-c,d)==1, which shares one final exponentiation.
+// Points are copied, since the engine normalizes them in place and keys are shared between goroutines.
+func pairingCheck(a *bls.PointG1, b *bls.PointG2, c *bls.PointG1, d *bls.PointG2) bool {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	pairing := bls.NewEngine()
+	pairing.AddPair(g1.New().Set(a), g2.New().Set(b))
+	pairing.AddPairInv(c, g2.New().Set(d))
+	return pairing.Check()
+}
+
+// Random 64-bit scalars for batch verification, which are enough to fold checks with negligible error
+func randBatchScalars(n int) []*bls.Fr {
+	scalars := make([]*bls.Fr, n)
+	b := make([]byte, 8)
+	for i := 0; i < n; i++ {
+		rand.Read(b)
+		scalars[i] = bls.NewFr().FromBytes(b)
 	}
-	return numerator, denominator
+	return scalars
 }
 
-// (a0+a1x+a2x^2)*(b0+b1x+b2x^2)
-func polyMul(p1 []int, p2 []int) []int {
-	r := make([]int, len(p1)+len(p2)-1)
-	for i := 0; i < len(p1); i++ {
-		for j := 0; j < len(p2); j++ {
-			r[i+j] += p1[i] * p2[j]
-		}
+func frFromInt(x int) *bls.Fr {
+	fr := bls.NewFr().FromBytes(big.NewInt(int64(abs(x))).Bytes())
+	if x < 0 {
+		fr.Neg(fr)
 	}
-	return r
+	return fr
 }
 
-// (a0+a1x+a2x^2)+(b0+b1x+b2x^2)
-func polyAdd(p1 []*big.Int, p2 []*big.Int) []*big.Int {
-	if len(p1) > len(p2) {
-		r := make([]*big.Int, len(p1))
-		for i := 0; i < len(p2); i++ {
-			r[i] = new(big.Int).Add(p1[i], p2[i])
-		}
-		for i := len(p2); i < len(p1); i++ {
-			r[i] = new(big.Int).Set(p1[i])
-		}
-		return r
-	} else {
-		r := make([]*big.Int, len(p2))
-		for i := 0; i < len(p1); i++ {
-			r[i] = new(big.Int).Add(p1[i], p2[i])
-		}
-		for i := len(p1); i < len(p2); i++ {
-			r[i] = new(big.Int).Set(p2[i])
-		}
-		return r
+func frsFromInts(xs []int) []*bls.Fr {
+	frs := make([]*bls.Fr, len(xs))
+	for i := range xs {
+		frs[i] = frFromInt(xs[i])
 	}
+	return frs
+}
+
+// Lagrange coefficients at x=0 over Fr, li=prod(xj/(xj-xi)), which fails with polynomial.ErrDuplicatePoint on repeated indices
+func lagrangeCoefficients(xs []int) ([]*bls.Fr, error) {
+	return polynomial.LagrangeCoefficients(frsFromInts(xs), bls.NewFr().Zero())
 }
 
 func feldman(matrix [][]int) (int, []int) {
@@ -206,7 +215,8 @@ func abs(a int) int {
 	return a
 }
 
-// To improve computation performance
+// Scaler clears the denominators of lagrange coefficients of any threshold participants, which the global public key is multiplied by.
+// Shares are combined over Fr, so that it is only kept for keys, and feldman is left for it alone.
 func getEncryptionScaler(size int, threshold int) int {
 	matrix := make([][]int, threshold) // size=threshold*threshold
 	return searchDLCM(matrix, 1, 0, 0, size, threshold)
@@ -232,22 +242,3 @@ func searchDLCM(matrix [][]int, l, pos, offset, size, threshold int) int {
 	}
 	return l
 }
-
-func getCombs(m int, n int) [][]int {
-	return searchCombs(make([]int, n), 0, 0, m, n)
-}
-
-func searchCombs(arr []int, pos, offset, m, n int) [][]int {
-	results := make([][]int, 0)
-	if pos == n {
-		comb := make([]int, n)
-		copy(comb, arr)
-		results = append(results, comb)
-		return results
-	}
-	for i := pos + offset; i < m; i++ {
-		arr[pos] = i
-		results = append(results, searchCombs(arr, pos+1, i-pos, m, n)...)
-	}
-	return results
-}
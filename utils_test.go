@@ -1,14 +1,21 @@
 package tpke
 
 import (
-	"math/big"
+	"encoding/hex"
 	"testing"
+
+	bls "github.com/kilic/bls12-381"
+	"github.com/txhsl/tpke/polynomial"
 )
 
 func TestRecover(t *testing.T) {
-	p := polyRecover([]int{1, 2, 3, 4, 5}, []*big.Int{big.NewInt(3), big.NewInt(7), big.NewInt(13), big.NewInt(21), big.NewInt(31)})
-	if p[0].Int64() != 1 || p[1].Int64() != 1 || p[2].Int64() != 1 || p[3].Int64() != 0 || p[4].Int64() != 0 {
-		t.Fatalf("recover failed. %v", p)
+	p, err := polynomial.Interpolate(frsFromInts([]int{1, 2, 3, 4, 5}), frsFromInts([]int{3, 7, 13, 21, 31}))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	coeff := p.Coefficients()
+	if len(coeff) != 3 || !coeff[0].IsOne() || !coeff[1].IsOne() || !coeff[2].IsOne() {
+		t.Fatalf("recover failed. %v", coeff)
 	}
 }
 
@@ -52,3 +59,58 @@ func TestDeterminant(t *testing.T) {
 		t.Fatalf("test failed.")
 	}
 }
+
+func TestLagrangeCoefficients(t *testing.T) {
+	poly := randomPoly(3)
+	xs := []int{2, 5, 7}
+	result := bls.NewFr().Zero()
+	coeff, err := lagrangeCoefficients(xs)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for i, x := range xs {
+		y := poly.evaluate(*frFromInt(x))
+		y.Mul(y, coeff[i])
+		result.Add(result, y)
+	}
+	if !result.Equal(poly.coeff[0]) {
+		t.Fatalf("recover failed.")
+	}
+	if _, err := lagrangeCoefficients([]int{2, 5, 2}); err != polynomial.ErrDuplicatePoint {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestExpandMessageXMD(t *testing.T) {
+	// Test vector of RFC 9380
+	out := expandMessageXMD([]byte{}, []byte("QUUX-V01-CS02-with-expander-SHA256-128"), 0x20)
+	if hex.EncodeToString(out) != "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235" {
+		t.Fatalf("unexpected output %x", out)
+	}
+}
+
+func TestPairingCheck(t *testing.T) {
+	g1 := bls.NewG1()
+	g2 := bls.NewG2()
+	a := RandScalar()
+	b := RandScalar()
+	ab := bls.NewFr()
+	ab.Mul(a, b)
+	// e(aG1,bG2)==e(abG1,G2)
+	p1 := g1.MulScalar(g1.New(), &bls.G1One, a)
+	p2 := g2.MulScalar(g2.New(), &bls.G2One, b)
+	p3 := g1.MulScalar(g1.New(), &bls.G1One, ab)
+	if !pairingCheck(p1, p2, p3, &bls.G2One) {
+		t.Fatalf("valid equation rejected")
+	}
+	if pairingCheck(p1, p2, p1, &bls.G2One) {
+		t.Fatalf("invalid equation accepted")
+	}
+	// Both sides are one
+	if !pairingCheck(g1.Zero(), p2, p1, g2.Zero()) {
+		t.Fatalf("trivial equation rejected")
+	}
+	if pairingCheck(g1.Zero(), p2, p1, p2) {
+		t.Fatalf("invalid equation accepted")
+	}
+}